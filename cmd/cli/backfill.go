@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/guil95/ports-service/database"
+	"github.com/guil95/ports-service/graceful"
+	"github.com/guil95/ports-service/internal/core/application"
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/repository"
+	"github.com/spf13/cobra"
+)
+
+var BackfillCodesCmd = &cobra.Command{
+	Use:          "backfill-codes",
+	Short:        "Resolve the ISO 3166 country and subdivision codes of the stored ports",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := domain.WithChangeSource(graceful.WaitForShutdown(), domain.ChangeSource{
+			Kind:  domain.ChangeSourceCLI,
+			Actor: "backfill-codes",
+		})
+
+		slog.Info("Starting backfill of the ISO 3166 codes")
+
+		db := database.NewPostgresDB()
+		defer db.Close()
+		service := application.NewService(repository.NewPostgresRepository(db), nil)
+
+		saved, err := service.BackfillCountryCodes(ctx)
+		if err != nil {
+			slog.Error("Backfill failed", "saved", saved, "error", err)
+			return err
+		}
+
+		slog.Info("Backfill completed successfully", "saved", saved)
+		fmt.Printf("%d ports updated\n", saved)
+		return nil
+	},
+}
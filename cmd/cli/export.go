@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/guil95/ports-service/database"
+	"github.com/guil95/ports-service/graceful"
+	"github.com/guil95/ports-service/internal/core/application"
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/repository"
+	"github.com/guil95/ports-service/internal/infra/adapters/writer"
+	"github.com/spf13/cobra"
+)
+
+// exportPageSize is the number of ports read per page, the export never holds more in memory.
+const exportPageSize = 500
+
+func init() {
+	ExportCmd.Flags().StringP("output", "o", "-", "Path of the GeoJSON file to write, - for the standard output")
+	ExportCmd.Flags().String("country-code", "", "Only export the ports of this ISO 3166-1 country")
+}
+
+var ExportCmd = &cobra.Command{
+	Use:          "export",
+	Short:        "Export ports as a GeoJSON FeatureCollection",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := graceful.WaitForShutdown()
+		output, _ := cmd.Flags().GetString("output")
+		countryCode, _ := cmd.Flags().GetString("country-code")
+
+		var out io.Writer = os.Stdout
+		if output != "-" {
+			file, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		buffered := bufio.NewWriter(out)
+
+		db := database.NewPostgresDB()
+		defer db.Close()
+		service := application.NewService(repository.NewPostgresRepository(db), nil)
+
+		slog.Info("Starting export", "output", output)
+
+		geo := writer.NewGeoJSONWriter(buffered)
+		query := domain.ListQuery{CountryCode: countryCode, Limit: exportPageSize}
+		exported := 0
+		for {
+			page, err := service.List(ctx, query)
+			if err != nil {
+				slog.Error("Export failed", "error", err)
+				return err
+			}
+			for _, port := range page.Ports {
+				if err := geo.Write(port, nil); err != nil {
+					return fmt.Errorf("failed to write port: %w", err)
+				}
+			}
+			exported += len(page.Ports)
+
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		if err := geo.Close(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := buffered.Flush(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		slog.Info("Export completed successfully", "exported", exported)
+		return nil
+	},
+}
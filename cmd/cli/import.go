@@ -2,37 +2,73 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/guil95/ports-service/database"
 	"github.com/guil95/ports-service/graceful"
+	"hash"
+	"io"
 	"log/slog"
 	"os"
+	"unicode/utf8"
 
 	"github.com/guil95/ports-service/internal/core/application"
+	"github.com/guil95/ports-service/internal/core/domain"
 	"github.com/guil95/ports-service/internal/infra/adapters/parser"
 	"github.com/guil95/ports-service/internal/infra/adapters/repository"
+	"github.com/guil95/ports-service/internal/infra/adapters/writer"
 	"github.com/spf13/cobra"
 )
 
 func init() {
-	ImportCmd.Flags().StringP("file", "f", "", "Path to JSON file (required)")
+	ImportCmd.Flags().StringP("file", "f", "", "Path to the file to import, - for the standard input; gzip, zstd and bzip2 files are decompressed (required)")
+	ImportCmd.Flags().String("format", "json", "Format of the file: json (object keyed by port ID), ndjson, json-array, geojson, csv or unlocode")
+	ImportCmd.Flags().String("csv-delimiter", ",", "Cell delimiter of a CSV file")
+	ImportCmd.Flags().String("csv-value-delimiter", "|", "Delimiter of the alias, regions and unlocs values inside a CSV cell")
+	ImportCmd.Flags().StringToString("csv-columns", nil, "Map port fields to CSV header names or column numbers, e.g. id=LOCODE,name=2")
+	ImportCmd.Flags().String("csv-header", "auto", "Whether the CSV file starts with a header: auto, yes or no")
+	ImportCmd.Flags().String("unlocode-functions", parser.UNLOCODESeaport, "UN/LOCODE function codes to import, e.g. 14 for seaports and airports; empty imports every location")
+	ImportCmd.Flags().String("mode", "upsert", "upsert creates and updates the ports of the file, sync also deletes the ports missing from it")
+	ImportCmd.Flags().Float64("max-delete-percent", domain.DefaultMaxDeletePercent, "Block the deletions of --mode=sync when they would remove more than this percentage of the ports")
+	ImportCmd.Flags().String("on-error", "abort", "What to do with a bad record: abort the import, or skip it and go on")
+	ImportCmd.Flags().String("reject-file", "", "NDJSON file receiving the records skipped with --on-error=skip")
+	ImportCmd.Flags().Int("max-errors", domain.NoErrorLimit, "Records --on-error=skip may skip before the import fails, -1 for no limit")
+	ImportCmd.Flags().Bool("dry-run", false, "Report the ports the import would create or update on the standard output, without saving them")
+	ImportCmd.Flags().String("dry-run-format", string(writer.DiffText), "Format of the dry-run report: text or json")
+	ImportCmd.Flags().String("loader", "insert", "How batches are written: insert (multi-row INSERT) or copy (COPY into a staging table, then merged)")
+	ImportCmd.Flags().Int("batch-size", domain.DefaultImportBatchSize, "Ports saved together in one statement")
+	ImportCmd.Flags().Int("writers", domain.DefaultImportWriters, "Batches saved concurrently while the file is read")
+	ImportCmd.Flags().Duration("max-batch-latency", domain.DefaultImportBatchLatency, "Save a batch that is not full once its first port waited this long")
+	ImportCmd.Flags().Bool("atomic", false, "Import the whole file in a single transaction, the ports change only if the import succeeds")
+	ImportCmd.Flags().Bool("resume", false, "Skip the ports an interrupted import of the same file already stored")
+	ImportCmd.Flags().Bool("skip-if-unchanged", false, "Import nothing when the last import, in the same mode, read the same file and succeeded")
 	_ = ImportCmd.MarkFlagRequired("file")
 }
 
 var ImportCmd = &cobra.Command{
 	Use:          "import",
-	Short:        "Import ports from a JSON file",
+	Short:        "Import ports from a JSON, NDJSON, GeoJSON, CSV or UN/LOCODE file",
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := graceful.WaitForShutdown()
 		filePath, _ := cmd.Flags().GetString("file")
+		newParser, err := parserFactory(cmd)
+		if err != nil {
+			return err
+		}
+		settings, err := newImportSettings(cmd)
+		if err != nil {
+			return err
+		}
+		defer settings.close()
+		ctx := graceful.WaitForShutdown()
 
 		slog.Info("Starting import process", "file", filePath)
 
 		result := make(chan error, 1)
 		go func() {
 			defer close(result)
-			result <- runImport(ctx, filePath)
+			result <- runImport(ctx, filePath, newParser, settings)
 		}()
 
 		select {
@@ -47,7 +83,7 @@ var ImportCmd = &cobra.Command{
 			slog.Info("Received shutdown signal, stopping import...")
 		}
 
-		err := <-result
+		err = <-result
 		if err != nil {
 			slog.Error("Import interrupted with error", "error", err)
 			return fmt.Errorf("import interrupted with error: %w", err)
@@ -57,26 +93,294 @@ var ImportCmd = &cobra.Command{
 	},
 }
 
-func runImport(ctx context.Context, filePath string) error {
-	file, err := os.Open(filePath)
+// parserFactory builds the parser of the --format flag, the CSV flags are checked before the file is opened.
+func parserFactory(cmd *cobra.Command) (func(io.Reader) domain.ParserPort, error) {
+	format, _ := cmd.Flags().GetString("format")
+	switch format {
+	case "json":
+		return parser.NewJSONParser, nil
+	case "ndjson":
+		return parser.NewNDJSONParser, nil
+	case "json-array":
+		return parser.NewJSONArrayParser, nil
+	case "geojson":
+		return parser.NewGeoJSONParser, nil
+	case "unlocode":
+		functions, _ := cmd.Flags().GetString("unlocode-functions")
+		return func(reader io.Reader) domain.ParserPort {
+			return parser.NewUNLOCODEParser(reader, functions)
+		}, nil
+	case "csv":
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected json, ndjson, json-array, geojson, csv or unlocode", format)
+	}
+
+	delimiter, _ := cmd.Flags().GetString("csv-delimiter")
+	valueDelimiter, _ := cmd.Flags().GetString("csv-value-delimiter")
+	columns, _ := cmd.Flags().GetStringToString("csv-columns")
+	header, _ := cmd.Flags().GetString("csv-header")
+
+	// a literal tab is awkward to pass in a shell
+	if delimiter == `\t` {
+		delimiter = "\t"
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return nil, fmt.Errorf("csv delimiter must be a single character, got %q", delimiter)
+	}
+
+	options := parser.CSVOptions{ValueDelimiter: valueDelimiter, Columns: columns}
+	options.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	switch header {
+	case "auto":
+		options.Header = parser.CSVHeaderAuto
+	case "yes":
+		options.Header = parser.CSVHeaderPresent
+	case "no":
+		options.Header = parser.CSVHeaderAbsent
+	default:
+		return nil, fmt.Errorf("unknown csv header mode %q, expected auto, yes or no", header)
+	}
+
+	return func(reader io.Reader) domain.ParserPort {
+		return parser.NewCSVParser(reader, options)
+	}, nil
+}
+
+// importSettings are the import options of the flags, with the files and reports they write to.
+type importSettings struct {
+	format     string
+	options    domain.ImportOptions
+	rejectFile *os.File
+	report     *writer.DiffWriter
+}
+
+// newImportSettings reads the flags of the import mode, batching, bad records, dry runs and resumes.
+func newImportSettings(cmd *cobra.Command) (*importSettings, error) {
+	onError, _ := cmd.Flags().GetString("on-error")
+	rejectFile, _ := cmd.Flags().GetString("reject-file")
+	maxErrors, _ := cmd.Flags().GetInt("max-errors")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	dryRunFormat, _ := cmd.Flags().GetString("dry-run-format")
+	mode, _ := cmd.Flags().GetString("mode")
+	maxDeletePercent, _ := cmd.Flags().GetFloat64("max-delete-percent")
+	resume, _ := cmd.Flags().GetBool("resume")
+	filePath, _ := cmd.Flags().GetString("file")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	writers, _ := cmd.Flags().GetInt("writers")
+	maxBatchLatency, _ := cmd.Flags().GetDuration("max-batch-latency")
+	loader, _ := cmd.Flags().GetString("loader")
+	atomic, _ := cmd.Flags().GetBool("atomic")
+	skipIfUnchanged, _ := cmd.Flags().GetBool("skip-if-unchanged")
+	format, _ := cmd.Flags().GetString("format")
+
+	settings := &importSettings{format: format, options: domain.ImportOptions{
+		MaxErrors:        maxErrors,
+		DryRun:           dryRun,
+		MaxDeletePercent: maxDeletePercent,
+		Resume:           resume,
+		BatchSize:        batchSize,
+		Writers:          writers,
+		MaxBatchLatency:  maxBatchLatency,
+		Atomic:           atomic,
+		SkipIfUnchanged:  skipIfUnchanged,
+	}}
+	if atomic && resume {
+		return nil, fmt.Errorf("--resume cannot be used with --atomic, a failed atomic import stores nothing to resume from")
+	}
+	if batchSize < 1 || writers < 1 || maxBatchLatency <= 0 {
+		return nil, fmt.Errorf("--batch-size and --writers must be at least 1 and --max-batch-latency positive")
+	}
+	switch loader {
+	case "insert":
+		if batchSize > repository.MaxSaveBulkPorts {
+			return nil, fmt.Errorf("--batch-size above %d needs --loader=copy", repository.MaxSaveBulkPorts)
+		}
+	case "copy":
+		settings.options.Loader = domain.LoadCopy
+	default:
+		return nil, fmt.Errorf("unknown --loader %q, expected insert or copy", loader)
+	}
+	if resume && filePath == "-" {
+		return nil, fmt.Errorf("--resume needs a file, the standard input cannot be read again")
+	}
+	if skipIfUnchanged && filePath == "-" {
+		return nil, fmt.Errorf("--skip-if-unchanged needs a file, the checksum of the standard input is unknown before it is read")
+	}
+	switch mode {
+	case "upsert":
+	case "sync":
+		settings.options.Mode = domain.ImportSync
+	default:
+		return nil, fmt.Errorf("unknown --mode %q, expected upsert or sync", mode)
+	}
+	if maxDeletePercent < 0 || maxDeletePercent > 100 {
+		return nil, fmt.Errorf("--max-delete-percent must be between 0 and 100")
+	}
+
+	switch onError {
+	case "abort":
+		if rejectFile != "" {
+			return nil, fmt.Errorf("--reject-file requires --on-error=skip")
+		}
+	case "skip":
+		settings.options.OnError = domain.ImportSkip
+	default:
+		return nil, fmt.Errorf("unknown --on-error mode %q, expected abort or skip", onError)
+	}
+	if maxErrors < domain.NoErrorLimit {
+		return nil, fmt.Errorf("--max-errors must be at least %d", domain.NoErrorLimit)
+	}
+
+	if dryRun {
+		report, err := writer.NewDiffWriter(os.Stdout, writer.DiffFormat(dryRunFormat))
+		if err != nil {
+			return nil, err
+		}
+		settings.report = report
+		settings.options.Diffs = report
+	}
+
+	if rejectFile != "" {
+		file, err := os.Create(rejectFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reject file: %w", err)
+		}
+		settings.rejectFile = file
+		settings.options.Rejects = writer.NewRejectWriter(file)
+	}
+
+	return settings, nil
+}
+
+func (s *importSettings) close() {
+	if s.rejectFile != nil {
+		_ = s.rejectFile.Close()
+	}
+}
+
+func runImport(ctx context.Context, filePath string, newParser func(io.Reader) domain.ParserPort, settings *importSettings) error {
+	source, actor, err := openImportFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return err
 	}
+	defer source.Close()
 
-	_, err = file.Stat()
+	run := domain.ImportRun{Source: actor, Format: settings.format}
+	var input io.Reader = source
+	if file, ok := source.(*os.File); ok {
+		if run.Checksum, run.Size, err = fingerprint(file); err != nil {
+			return err
+		}
+		settings.options.Fingerprint = run.Checksum
+	} else {
+		// the standard input cannot be read twice, it is measured as it is imported
+		digest := newSourceDigest(source)
+		settings.options.Digest = digest
+		input = digest
+	}
+
+	reader, err := parser.Decompress(input)
 	if err != nil {
-		return fmt.Errorf("could not obtain stat, handle error: %w", err)
+		return fmt.Errorf("failed to read file: %w", err)
 	}
+	defer reader.Close()
 
 	db := database.NewPostgresDB()
 	repo := repository.NewPostgresRepository(db)
-	jsonParser := parser.NewJSONParser(file)
-	service := application.NewService(repo, jsonParser)
+	service := application.NewService(repo, newParser(reader))
 
-	if err := service.ImportPorts(ctx); err != nil {
+	ctx = domain.WithChangeSource(ctx, domain.ChangeSource{Kind: domain.ChangeSourceImport, Actor: actor})
+	result, err := service.RunImport(ctx, run, settings.options)
+	if result != nil {
+		summary := result.Summary
+		slog.Info("Import summary", "run", result.ID, "outcome", result.Outcome, "parsed", summary.Parsed, "imported", summary.Imported,
+			"created", summary.Created, "updated", summary.Updated, "unchanged", summary.Unchanged,
+			"rejected", summary.Rejected, "skipped", summary.Skipped, "deleted", summary.Deleted)
+	}
+	if err != nil {
+		if settings.report != nil {
+			// end the report so the JSON format stays a single document
+			var summary domain.ImportSummary
+			if result != nil {
+				summary = result.Summary
+			}
+			_ = settings.report.Fail(summary, err)
+		}
 		return fmt.Errorf("import failed: %w", err)
 	}
 
+	if settings.report != nil {
+		// the report is the output of a dry run, nothing was imported
+		return settings.report.Close(result.Summary)
+	}
+	if result.Outcome == domain.ImportUnchanged {
+		fmt.Println("File unchanged since the last import, nothing imported.")
+		return nil
+	}
 	fmt.Println("Ports imported successfully!")
 	return nil
 }
+
+// openImportFile opens the file to import, "-" reads the standard input. The actor names the source in the port history.
+func openImportFile(filePath string) (io.ReadCloser, string, error) {
+	if filePath == "-" {
+		return io.NopCloser(os.Stdin), "stdin", nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	if _, err := file.Stat(); err != nil {
+		file.Close()
+		return nil, "", fmt.Errorf("could not obtain stat, handle error: %w", err)
+	}
+
+	if utf8.RuneCountInString(filePath) > domain.MaxImportSourceLength {
+		file.Close()
+		return nil, "", fmt.Errorf("file path too long to be recorded, at most %d characters", domain.MaxImportSourceLength)
+	}
+
+	return file, filePath, nil
+}
+
+// fingerprint identifies the content of the file for its checkpoints and import runs, with its size, and rewinds it.
+func fingerprint(file *os.File) (string, int64, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// sourceDigest hashes and counts the bytes of a source as they are read.
+type sourceDigest struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+}
+
+func newSourceDigest(r io.Reader) *sourceDigest {
+	return &sourceDigest{r: r, hash: sha256.New()}
+}
+
+func (d *sourceDigest) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.hash.Write(p[:n])
+	d.size += int64(n)
+	return n, err
+}
+
+// Digest reads what the parser left of the source, e.g. the whitespace after the last record, so the
+// checksum covers the whole source.
+func (d *sourceDigest) Digest() (string, int64, error) {
+	if _, err := io.Copy(io.Discard, d); err != nil {
+		return "", 0, err
+	}
+	return "sha256:" + hex.EncodeToString(d.hash.Sum(nil)), d.size, nil
+}
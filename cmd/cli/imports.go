@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/guil95/ports-service/database"
+	"github.com/guil95/ports-service/graceful"
+	"github.com/guil95/ports-service/internal/core/application"
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/repository"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	ImportsListCmd.Flags().Int("limit", 20, "Number of runs to show, the latest first, at most 100")
+	ImportsListCmd.Flags().Bool("json", false, "Print the runs as a JSON array")
+	ImportsCmd.AddCommand(ImportsListCmd)
+}
+
+var ImportsCmd = &cobra.Command{
+	Use:   "imports",
+	Short: "Inspect the history of the import runs",
+}
+
+var ImportsListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the latest import runs, from the command line and the HTTP import jobs",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		ctx := graceful.WaitForShutdown()
+
+		db := database.NewPostgresDB()
+		defer db.Close()
+		service := application.NewService(repository.NewPostgresRepository(db), nil)
+
+		runs, err := service.ImportRuns(ctx, limit)
+		if err != nil {
+			slog.Error("Listing import runs failed", "error", err)
+			return err
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(runs)
+		}
+		return printImportRuns(runs)
+	},
+}
+
+// printImportRuns writes the runs as a table, the checksum is shortened to its first 12 hex digits.
+func printImportRuns(runs []domain.ImportRun) error {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tSTARTED\tDURATION\tSOURCE\tMODE\tOUTCOME\tPARSED\tWRITTEN\tREJECTED\tCHECKSUM\tERROR")
+	for _, run := range runs {
+		duration := "-"
+		if run.FinishedAt != nil {
+			duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond).String()
+		}
+		mode := run.Mode
+		if run.DryRun {
+			mode += " (dry run)"
+		}
+		checksum := "-"
+		if len(run.Checksum) > len("sha256:")+12 {
+			checksum = run.Checksum[len("sha256:") : len("sha256:")+12]
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+			run.ID, run.StartedAt.Local().Format(time.DateTime), duration, run.Source, mode, run.Outcome,
+			run.Summary.Parsed, run.Summary.Imported, run.Summary.Rejected, checksum, run.Error)
+	}
+
+	return table.Flush()
+}
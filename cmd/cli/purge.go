@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/guil95/ports-service/database"
+	"github.com/guil95/ports-service/graceful"
+	"github.com/guil95/ports-service/internal/core/application"
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/repository"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	PurgeCmd.Flags().Duration("older-than", 30*24*time.Hour, "Minimum time since the port was deleted, e.g. 720h")
+}
+
+var PurgeCmd = &cobra.Command{
+	Use:          "purge",
+	Short:        "Permanently remove ports deleted longer ago than the given age",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		ctx := domain.WithChangeSource(graceful.WaitForShutdown(), domain.ChangeSource{
+			Kind:  domain.ChangeSourceCLI,
+			Actor: "purge",
+		})
+
+		slog.Info("Starting purge", "older_than", olderThan.String())
+
+		db := database.NewPostgresDB()
+		defer db.Close()
+		repo := repository.NewPostgresRepository(db)
+		service := application.NewService(repo, nil)
+
+		purged, err := service.Purge(ctx, olderThan)
+		if err != nil {
+			slog.Error("Purge failed", "error", err)
+			return err
+		}
+
+		slog.Info("Purge completed successfully", "purged", purged)
+		fmt.Printf("%d ports purged\n", purged)
+		return nil
+	},
+}
@@ -22,7 +22,13 @@ var ServeCmd = &cobra.Command{
 		db := database.NewPostgresDB()
 		repo := repository.NewPostgresRepository(db)
 		service := application.NewService(repo, nil)
-		httpHandler := handler.NewHTTPHandler(service)
+		importJobs := application.NewImportJobs(repo)
+		// the running import jobs are canceled, their state is recorded before the process exits
+		defer importJobs.Close()
+		if err := importJobs.RecoverImportJobs(cmd.Context()); err != nil {
+			slog.Error("Finishing abandoned import jobs failed", "error", err)
+		}
+		httpHandler := handler.NewHTTPHandler(service, importJobs)
 
 		server := &http.Server{
 			Addr:    ":8080",
@@ -19,6 +19,10 @@ func main() {
 
 	cli.RootCmd.AddCommand(cli.ServeCmd)
 	cli.RootCmd.AddCommand(cli.ImportCmd)
+	cli.RootCmd.AddCommand(cli.ImportsCmd)
+	cli.RootCmd.AddCommand(cli.PurgeCmd)
+	cli.RootCmd.AddCommand(cli.ExportCmd)
+	cli.RootCmd.AddCommand(cli.BackfillCodesCmd)
 
 	if err := cli.RootCmd.Execute(); err != nil {
 		slog.Error("Command execution failed", "error", err)
@@ -0,0 +1,604 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// importRun is the state of one ImportPorts call. The reader goroutine, the one of ImportPorts, validates
+// the ports of the parser and groups them in batches, the writers save the batches of the queue.
+type importRun struct {
+	repo    domain.RepositoryPort
+	options domain.ImportOptions
+
+	// fields of the reader
+	batch []domain.Port
+	seq   int
+	timer *time.Timer
+	// checkpoint is the progress of the resumed import until the import passes it
+	checkpoint *domain.Checkpoint
+	resumed    bool
+	// previouslyImported are the ports stored by the resumed import
+	previouslyImported int64
+
+	queue   chan importBatch
+	writers sync.WaitGroup
+	// failed is closed when a writer fails, failure is the error of the first failed batch of the file
+	failed chan struct{}
+
+	// mu guards the fields shared by the reader and the writers
+	mu         sync.Mutex
+	summary    domain.ImportSummary
+	failure    error
+	failureSeq int
+	// seen are the IDs of the file, kept by domain.ImportSync imports; unkeyed tells a rejected record
+	// had no port ID, its port could be any stored one
+	seen    map[string]struct{}
+	unkeyed bool
+
+	// checkpointMu orders the checkpoints of the writers, see commit
+	checkpointMu  sync.Mutex
+	committedSeq  int
+	committed     int64
+	storedBatches map[int]storedBatch
+
+	// reportMu orders the progress reports, reports counts them under mu and reported is the last one made
+	reportMu sync.Mutex
+	reports  int
+	reported int
+}
+
+// importBatch is a batch of the queue, seq is its position in the file.
+type importBatch struct {
+	seq   int
+	ports []domain.Port
+}
+
+// storedBatch is a stored batch the checkpoint has not passed yet, a batch before it is not stored.
+type storedBatch struct {
+	last     domain.Port
+	imported int64
+}
+
+// ImportPorts saves the ports of the parser in batches of options.BatchSize, options.Writers batches at a
+// time while the parser goes on. A batch is saved when it is full or once its first port waited
+// options.MaxBatchLatency. With domain.ImportAbort the first bad record stops the import, with
+// domain.ImportSkip bad records are rejected until options.MaxErrors is exceeded. The ports read before the
+// import stops are saved, the batches following a batch that could not be saved are not, and the error
+// returned is the one of the first failed batch of the file. A dry run compares the batches with the
+// stored ports, in the order of the file, and saves nothing. A domain.ImportSync import that reaches the
+// end of the file then deletes the ports the file did not list.
+//
+// With options.Fingerprint a checkpoint is saved as the batches are stored and deleted at the end of the
+// import, options.Resume skips the ports up to the checkpoint of an interrupted import of the same file.
+//
+// An options.Atomic import runs in a single transaction: nothing is saved, nor deleted, unless the whole
+// file is imported, and the summary of a failed atomic import counts no imported nor deleted port.
+func (s *service) ImportPorts(ctx context.Context, options domain.ImportOptions) (*domain.ImportSummary, error) {
+	if !options.Atomic {
+		return s.importPorts(ctx, options)
+	}
+
+	// the batches of the transaction are saved one at a time, and a checkpoint would be rolled back with them
+	options.Writers, options.Fingerprint = 1, ""
+	var summary *domain.ImportSummary
+	err := s.repo.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		summary, err = s.importPorts(ctx, options)
+		return err
+	})
+	if err != nil && summary != nil {
+		// the transaction rolled back every write
+		summary.Imported, summary.Deleted = 0, 0
+		summary.Created, summary.Updated, summary.Unchanged = 0, 0, 0
+	}
+	return summary, err
+}
+
+func (s *service) importPorts(ctx context.Context, options domain.ImportOptions) (*domain.ImportSummary, error) {
+	// stops the parser when the import ends before the end of the file
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if options.BatchSize <= 0 {
+		options.BatchSize = domain.DefaultImportBatchSize
+	}
+	if options.Writers <= 0 {
+		options.Writers = domain.DefaultImportWriters
+	}
+	if options.DryRun {
+		// a single writer reports the diffs of a dry run in the order of the file
+		options.Writers = 1
+	}
+	if options.MaxBatchLatency <= 0 {
+		options.MaxBatchLatency = domain.DefaultImportBatchLatency
+	}
+
+	run := &importRun{
+		repo:          s.repo,
+		options:       options,
+		seen:          map[string]struct{}{},
+		queue:         make(chan importBatch, options.Writers),
+		failed:        make(chan struct{}),
+		storedBatches: map[int]storedBatch{},
+	}
+	if options.Resume && options.Fingerprint != "" {
+		checkpoint, err := s.repo.FindCheckpoint(ctx, options.Fingerprint)
+		switch {
+		case errors.Is(err, domain.ErrCheckpointNotFound):
+			slog.WarnContext(ctx, "no checkpoint to resume from, importing the whole file", "fingerprint", options.Fingerprint)
+		case err != nil:
+			return &run.summary, err
+		default:
+			slog.InfoContext(ctx, "resuming import", "last_key", checkpoint.LastKey, "offset", checkpoint.Offset, "imported", checkpoint.Imported)
+			run.checkpoint, run.previouslyImported = checkpoint, checkpoint.Imported
+		}
+	}
+
+	run.timer = time.NewTimer(options.MaxBatchLatency)
+	run.timer.Stop()
+	defer run.timer.Stop()
+	for range options.Writers {
+		run.writers.Add(1)
+		go run.write(ctx)
+	}
+
+	portCh, errCh := s.parser.Parse(ctx) // pipeline pattern
+	for {
+		select {
+		case <-ctx.Done():
+			// Save items that remains on the batch
+			return &run.summary, run.stop(ctx.Err())
+		case <-run.failed:
+			return &run.summary, run.stop(nil)
+		case <-run.timer.C:
+			run.enqueue()
+		case port, ok := <-portCh:
+			if !ok {
+				// the parser may have sent its last error just before closing
+				select {
+				case err := <-errCh:
+					if err := run.parseError(ctx, err); err != nil {
+						return &run.summary, run.stop(err)
+					}
+				default:
+				}
+				return &run.summary, run.finish(ctx)
+			}
+
+			if err := run.add(ctx, port); err != nil {
+				return &run.summary, run.stop(err)
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			if err := run.parseError(ctx, err); err != nil {
+				return &run.summary, run.stop(err)
+			}
+		}
+	}
+}
+
+func (r *importRun) add(ctx context.Context, port domain.Port) error {
+	r.parsed()
+	if stored, err := r.skipStored(port); stored || err != nil {
+		return err
+	}
+
+	normalizePort(&port)
+	if err := port.Validate(); err != nil {
+		return r.reject(ctx, domain.RecordError{Key: portKey(port), Offset: port.Offset, Err: err})
+	}
+
+	r.see(portKey(port))
+	r.batch = append(r.batch, port)
+	switch len(r.batch) {
+	case r.options.BatchSize:
+		r.enqueue()
+	case 1:
+		r.timer.Reset(r.options.MaxBatchLatency)
+	}
+	return nil
+}
+
+// enqueue hands the batch to the writers, it waits while the queue is full. The batch is dropped
+// when a writer failed, the writers would not save it.
+func (r *importRun) enqueue() {
+	r.timer.Stop()
+	if len(r.batch) == 0 {
+		return
+	}
+
+	select {
+	case r.queue <- importBatch{seq: r.seq, ports: r.batch}:
+	case <-r.failed:
+	}
+	r.seq++
+	r.batch = nil
+}
+
+// parseError handles an error of the parser, only a *domain.RecordError can be skipped.
+func (r *importRun) parseError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var recordErr *domain.RecordError
+	if !errors.As(err, &recordErr) {
+		return err
+	}
+	r.parsed()
+	if r.options.OnError == domain.ImportSkip {
+		return r.reject(ctx, *recordErr)
+	}
+
+	return err
+}
+
+// reject records a bad record, or returns the error stopping the import when it cannot go on.
+// The reader and the writers reject records.
+func (r *importRun) reject(ctx context.Context, record domain.RecordError) error {
+	if r.options.OnError != domain.ImportSkip {
+		return fmt.Errorf("port %s: %w", record.Key, record.Err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// a rejected port is still in the file, a sync must not delete it
+	if record.Unkeyed || record.Key == unknownPortKey {
+		r.unkeyed = true
+	} else {
+		r.seeLocked(record.Key)
+	}
+	r.summary.Rejected++
+	slog.WarnContext(ctx, "rejected record", "key", record.Key, "offset", record.Offset, "error", record.Err)
+	if r.options.Rejects != nil {
+		if err := r.options.Rejects.Reject(ctx, record); err != nil {
+			return fmt.Errorf("error to write rejected record: %w", err)
+		}
+	}
+
+	if r.options.MaxErrors != domain.NoErrorLimit && r.summary.Rejected > int64(r.options.MaxErrors) {
+		return fmt.Errorf("%w: %d rejected, the limit is %d", domain.ErrErrorBudgetExceeded, r.summary.Rejected, r.options.MaxErrors)
+	}
+	return nil
+}
+
+// stop saves the valid ports read so far and returns err, or the error of the writers when err is nil.
+func (r *importRun) stop(err error) error {
+	if saveErr := r.drain(); saveErr != nil {
+		if err == nil {
+			return saveErr
+		}
+		return fmt.Errorf("error to save batch: %v; error: %w", saveErr, err)
+	}
+	return err
+}
+
+// drain queues the last batch and waits for the writers, it returns the error of the first failed batch.
+func (r *importRun) drain() error {
+	r.enqueue()
+	close(r.queue)
+	r.writers.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failure
+}
+
+// write saves the batches of the queue until it is closed. The batches following a failed batch are skipped.
+func (r *importRun) write(ctx context.Context) {
+	defer r.writers.Done()
+
+	for batch := range r.queue {
+		r.mu.Lock()
+		skip := r.failure != nil && batch.seq > r.failureSeq
+		r.mu.Unlock()
+		if skip {
+			continue
+		}
+
+		if err := r.flush(ctx, batch); err != nil {
+			r.fail(batch.seq, err)
+			continue
+		}
+		r.progress(ctx)
+	}
+}
+
+// progress reports the summary to options.Progress. The report is made out of mu, the reader and the
+// other writers go on meanwhile; a report older than the last one made is dropped, so they never go backwards.
+func (r *importRun) progress(ctx context.Context) {
+	if r.options.Progress == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.reports++
+	report, summary := r.reports, r.summary
+	r.mu.Unlock()
+
+	r.reportMu.Lock()
+	defer r.reportMu.Unlock()
+	if report < r.reported {
+		return
+	}
+	r.reported = report
+	r.options.Progress.Progress(ctx, summary)
+}
+
+// fail keeps the error of the first failed batch of the file, whatever batch failed first.
+func (r *importRun) fail(seq int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failure == nil {
+		close(r.failed)
+	} else if seq > r.failureSeq {
+		return
+	}
+	r.failure, r.failureSeq = err, seq
+}
+
+// flush saves the batch with the loader of the options. With domain.ImportSkip a batch the database
+// refuses is saved port by port, with plain inserts, to reject only the ports it refuses.
+func (r *importRun) flush(ctx context.Context, batch importBatch) error {
+	if r.options.DryRun {
+		return r.compare(ctx, batch.ports)
+	}
+
+	save := r.repo.SaveBulk
+	if r.options.Loader == domain.LoadCopy {
+		save = r.repo.CopyBulk
+	}
+	result, err := save(ctx, batch.ports)
+	if err == nil {
+		r.imported(int64(len(batch.ports)), result)
+		return r.commit(ctx, batch, int64(len(batch.ports)))
+	}
+	if r.options.OnError != domain.ImportSkip || ctx.Err() != nil {
+		return err
+	}
+
+	slog.WarnContext(ctx, "error to save batch, saving its ports one by one", "error", err)
+	var imported int64
+	for _, port := range batch.ports {
+		result, err := r.repo.SaveBulk(ctx, []domain.Port{port})
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if err := r.reject(ctx, domain.RecordError{Key: portKey(port), Offset: port.Offset, Err: err}); err != nil {
+				return err
+			}
+			continue
+		}
+		r.imported(1, result)
+		imported++
+	}
+	return r.commit(ctx, batch, imported)
+}
+
+// imported counts n saved ports, split by the result of their save. The deleted ports it skipped are not imported.
+func (r *importRun) imported(n int64, result domain.SaveResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Imported += n - result.Skipped
+	r.summary.Skipped += result.Skipped
+	r.summary.Created += result.Created
+	r.summary.Updated += result.Updated
+	r.summary.Unchanged += result.Unchanged
+}
+
+// compare counts what saving the batch would do to the stored ports and reports the ports it would change.
+func (r *importRun) compare(ctx context.Context, batch []domain.Port) error {
+	ids := make([]string, 0, len(batch))
+	for _, port := range batch {
+		ids = append(ids, portKey(port))
+	}
+	stored, err := r.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	deleted := map[string]bool{}
+	if len(stored) < len(ids) {
+		deletedIDs, err := r.repo.DeletedIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range deletedIDs {
+			deleted[id] = true
+		}
+	}
+
+	for _, port := range batch {
+		diff := domain.PortDiff{ID: portKey(port), Action: domain.DiffCreated}
+		if deleted[diff.ID] {
+			// saving leaves deleted ports alone
+			r.mu.Lock()
+			r.summary.Skipped++
+			r.mu.Unlock()
+			continue
+		}
+		if current, found := stored[diff.ID]; found {
+			diff.Action, diff.Changes = domain.DiffUpdated, current.Diff(port)
+		}
+
+		r.mu.Lock()
+		switch {
+		case diff.Action == domain.DiffCreated:
+			r.summary.Created++
+		case len(diff.Changes) == 0:
+			r.summary.Unchanged++
+		default:
+			r.summary.Updated++
+		}
+		r.mu.Unlock()
+		if len(diff.Changes) == 0 && diff.Action == domain.DiffUpdated {
+			continue
+		}
+		if r.options.Diffs != nil {
+			if err := r.options.Diffs.Diff(ctx, diff); err != nil {
+				return fmt.Errorf("error to write diff: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// finish ends an import that read the whole file.
+func (r *importRun) finish(ctx context.Context) error {
+	if err := r.drain(); err != nil {
+		return err
+	}
+	if r.checkpoint != nil && !r.resumed {
+		return fmt.Errorf("%w: record %s at offset %d not found", domain.ErrCheckpointMismatch, r.checkpoint.LastKey, r.checkpoint.Offset)
+	}
+	if r.options.Mode == domain.ImportSync {
+		if err := r.sync(ctx); err != nil {
+			return err
+		}
+	}
+
+	if r.options.Fingerprint != "" && !r.options.DryRun {
+		return r.repo.DeleteCheckpoint(ctx, r.options.Fingerprint)
+	}
+	return nil
+}
+
+// skipStored tells whether a resumed import already stored the port, the ports up to the checkpoint are.
+// The record at the checkpoint offset must be the checkpoint key, else the file is read differently
+// (another format or other CSV columns) and the offsets cannot be trusted.
+func (r *importRun) skipStored(port domain.Port) (bool, error) {
+	if r.checkpoint == nil {
+		return false, nil
+	}
+
+	if port.Offset > r.checkpoint.Offset {
+		if !r.resumed {
+			return false, fmt.Errorf("%w: record %s at offset %d not found", domain.ErrCheckpointMismatch, r.checkpoint.LastKey, r.checkpoint.Offset)
+		}
+		r.checkpoint = nil
+		return false, nil
+	}
+
+	if port.Offset == r.checkpoint.Offset {
+		if key := portKey(port); key != r.checkpoint.LastKey {
+			return false, fmt.Errorf("%w: record at offset %d is %s, expected %s", domain.ErrCheckpointMismatch, port.Offset, key, r.checkpoint.LastKey)
+		}
+		r.resumed = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seeLocked(portKey(port))
+	r.summary.Skipped++
+	return true, nil
+}
+
+// commit records that the batch is stored. The writers may store the batches out of order, the checkpoint
+// only moves over the batches stored without a gap since the start of the file: a resumed import must not
+// skip a batch that a writer had not stored yet.
+func (r *importRun) commit(ctx context.Context, batch importBatch, imported int64) error {
+	if r.options.Fingerprint == "" {
+		return nil
+	}
+
+	r.checkpointMu.Lock()
+	defer r.checkpointMu.Unlock()
+
+	r.storedBatches[batch.seq] = storedBatch{last: batch.ports[len(batch.ports)-1], imported: imported}
+	var last *domain.Port
+	for {
+		stored, found := r.storedBatches[r.committedSeq]
+		if !found {
+			break
+		}
+		delete(r.storedBatches, r.committedSeq)
+		r.committedSeq++
+		r.committed += stored.imported
+		last = &stored.last
+	}
+	if last == nil {
+		return nil
+	}
+
+	return r.repo.SaveCheckpoint(ctx, domain.Checkpoint{
+		Fingerprint: r.options.Fingerprint,
+		Source:      domain.ChangeSourceFromContext(ctx).Actor,
+		LastKey:     portKey(*last),
+		Offset:      last.Offset,
+		Imported:    r.previouslyImported + r.committed,
+	})
+}
+
+// parsed counts a record read from the file.
+func (r *importRun) parsed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Parsed++
+}
+
+func (r *importRun) see(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seeLocked(id)
+}
+
+func (r *importRun) seeLocked(id string) {
+	if r.options.Mode == domain.ImportSync {
+		r.seen[id] = struct{}{}
+	}
+}
+
+// sync deletes the stored ports the file did not list, unless they are more than options.MaxDeletePercent
+// of the stored ports or a rejected record had no port ID. A dry run only reports them.
+func (r *importRun) sync(ctx context.Context) error {
+	if r.unkeyed {
+		slog.WarnContext(ctx, "sync deletions skipped, a rejected record had no port ID")
+		return nil
+	}
+
+	seen := make([]string, 0, len(r.seen))
+	for id := range r.seen {
+		seen = append(seen, id)
+	}
+	missing, err := r.repo.MissingIDs(ctx, seen)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	stored, err := r.repo.Count(ctx)
+	if err != nil {
+		return err
+	}
+	if share := 100 * float64(len(missing)) / float64(stored); share > r.options.MaxDeletePercent {
+		slog.ErrorContext(ctx, "sync deletions blocked", "missing", len(missing), "stored", stored, "limit", r.options.MaxDeletePercent)
+		return fmt.Errorf("%w: %d of %d ports (%.1f%%), the limit is %g%%",
+			domain.ErrSyncLimitExceeded, len(missing), stored, share, r.options.MaxDeletePercent)
+	}
+
+	if r.options.DryRun {
+		r.summary.Deleted = int64(len(missing))
+		for _, id := range missing {
+			if r.options.Diffs == nil {
+				break
+			}
+			if err := r.options.Diffs.Diff(ctx, domain.PortDiff{ID: id, Action: domain.DiffDeleted}); err != nil {
+				return fmt.Errorf("error to write diff: %w", err)
+			}
+		}
+		return nil
+	}
+
+	r.summary.Deleted, err = r.repo.DeleteMany(ctx, missing)
+	return err
+}
@@ -0,0 +1,260 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+const (
+	// progressInterval is how often a running job saves its counters, which tells the other servers it is
+	// still running, and notices a cancellation requested through another server.
+	progressInterval = time.Second
+	// staleJobAge is how long a running job may go without saving its counters before it is taken for
+	// a job of a server that stopped without recording it.
+	staleJobAge = time.Minute
+)
+
+var (
+	errJobCanceled   = errors.New("canceled on request")
+	errServerStopped = errors.New("canceled by a server shutdown")
+	errJobAbandoned  = errors.New("abandoned by a server that stopped")
+)
+
+type importJobs struct {
+	repo             domain.RepositoryPort
+	progressInterval time.Duration
+	// ctx is the parent of the job contexts, canceled by Close
+	ctx  context.Context
+	stop context.CancelCauseFunc
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelCauseFunc
+	running sync.WaitGroup
+}
+
+// NewImportJobs runs the imports of the HTTP server in the background. The state of the jobs is kept by
+// the repository, any server can report it or request a cancellation.
+func NewImportJobs(repo domain.RepositoryPort) domain.ImportJobPort {
+	ctx, stop := context.WithCancelCause(context.Background())
+	return &importJobs{
+		repo:             repo,
+		progressInterval: progressInterval,
+		ctx:              ctx,
+		stop:             stop,
+		cancels:          map[string]context.CancelCauseFunc{},
+	}
+}
+
+// StartImportJob records the job and runs request.Options with its parser. The job is an import run of the
+// change source of ctx, the run has the ID of the job.
+func (j *importJobs) StartImportJob(ctx context.Context, request domain.ImportJobRequest) (*domain.ImportJob, error) {
+	release := func() {
+		if request.Release != nil {
+			request.Release()
+		}
+	}
+
+	id, err := newImportID()
+	if err != nil {
+		release()
+		return nil, err
+	}
+	job := domain.ImportJob{ID: id, Status: domain.ImportJobRunning, Format: request.Format, Source: request.Source}
+	if err := j.repo.CreateImportJob(ctx, job); err != nil {
+		release()
+		return nil, err
+	}
+
+	source := domain.ChangeSourceFromContext(ctx)
+	jobCtx, cancel := context.WithCancelCause(domain.WithChangeSource(j.ctx, domain.ChangeSource{
+		Kind:  domain.ChangeSourceImport,
+		Actor: source.Actor,
+	}))
+	j.mu.Lock()
+	j.cancels[job.ID] = cancel
+	j.mu.Unlock()
+
+	j.running.Add(1)
+	go func() {
+		defer j.running.Done()
+		defer release()
+		defer j.forget(job.ID)
+
+		j.run(jobCtx, cancel, job, request)
+	}()
+
+	return j.repo.FindImportJob(ctx, job.ID)
+}
+
+func (j *importJobs) run(ctx context.Context, cancel context.CancelCauseFunc, job domain.ImportJob, request domain.ImportJobRequest) {
+	slog.InfoContext(ctx, "import job started", "job", job.ID, "format", job.Format, "source", job.Source)
+
+	progress := &jobProgress{ctx: ctx, cancel: cancel, repo: j.repo, id: job.ID}
+	stopProgress := progress.saveEvery(j.progressInterval)
+	options := request.Options
+	options.Progress = progress
+	run, err := NewService(j.repo, request.Parser).RunImport(ctx, domain.ImportRun{
+		ID:       job.ID,
+		Source:   job.Source,
+		Format:   job.Format,
+		Size:     request.Size,
+		Checksum: request.Checksum,
+	}, options)
+	stopProgress()
+	if run != nil {
+		job.Summary = run.Summary
+	}
+
+	switch {
+	case err == nil && run.Outcome == domain.ImportUnchanged:
+		job.Status = domain.ImportJobUnchanged
+	case err == nil:
+		job.Status = domain.ImportJobSucceeded
+	case ctx.Err() != nil:
+		job.Status, job.Error = domain.ImportJobCanceled, context.Cause(ctx).Error()
+	default:
+		job.Status, job.Error = domain.ImportJobFailed, err.Error()
+	}
+	slog.InfoContext(ctx, "import job finished", "job", job.ID, "status", job.Status, "imported", job.Summary.Imported, "error", job.Error)
+
+	// the job context may be canceled, the outcome is recorded anyway
+	if err := j.repo.FinishImportJob(context.WithoutCancel(ctx), job); err != nil {
+		slog.ErrorContext(ctx, "error to record import job", "job", job.ID, "error", err)
+	}
+}
+
+func (j *importJobs) forget(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.cancels, id)
+}
+
+func (j *importJobs) FindImportJob(ctx context.Context, id string) (*domain.ImportJob, error) {
+	return j.repo.FindImportJob(ctx, id)
+}
+
+// CancelImportJob stops the job at once when this server runs it, another server stops it at its next
+// progress update.
+func (j *importJobs) CancelImportJob(ctx context.Context, id string) (*domain.ImportJob, error) {
+	if err := j.repo.RequestImportJobCancel(ctx, id); err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	cancel, found := j.cancels[id]
+	j.mu.Unlock()
+	if found {
+		cancel(errJobCanceled)
+	}
+
+	return j.repo.FindImportJob(ctx, id)
+}
+
+// RecoverImportJobs finishes the jobs left running by servers that stopped without recording them, now and
+// then every staleJobAge until Close: a job that did not save its counters for staleJobAge is canceled when
+// its cancellation was requested, failed otherwise, and so is its run.
+func (j *importJobs) RecoverImportJobs(ctx context.Context) error {
+	err := j.finishStaleJobs(ctx)
+
+	j.running.Add(1)
+	go func() {
+		defer j.running.Done()
+
+		ticker := time.NewTicker(staleJobAge)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-ticker.C:
+				_ = j.finishStaleJobs(j.ctx)
+			}
+		}
+	}()
+
+	return err
+}
+
+func (j *importJobs) finishStaleJobs(ctx context.Context) error {
+	finished, err := j.repo.FinishStaleImportJobs(ctx, time.Now().Add(-staleJobAge), errJobAbandoned.Error())
+	if err != nil {
+		slog.ErrorContext(ctx, "error to finish stale import jobs", "error", err)
+		return err
+	}
+	if finished > 0 {
+		slog.WarnContext(ctx, "finished import jobs abandoned by a stopped server", "jobs", finished)
+	}
+	return nil
+}
+
+func (j *importJobs) Close() {
+	j.stop(errServerStopped)
+	j.running.Wait()
+}
+
+// jobProgress saves the counters of a running job every progressInterval, even when they did not change.
+type jobProgress struct {
+	// ctx is the job context: the one of Progress holds the transaction of an atomic import,
+	// the counters would only show once the import is over
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	repo   domain.RepositoryPort
+	id     string
+
+	mu      sync.Mutex
+	summary domain.ImportSummary
+}
+
+// Progress keeps the counters for the next save, the import does not wait for the database.
+func (p *jobProgress) Progress(_ context.Context, summary domain.ImportSummary) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.summary = summary
+}
+
+// saveEvery saves the counters every interval until the returned function is called, which waits for
+// the save in progress.
+func (p *jobProgress) saveEvery(interval time.Duration) (stop func()) {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.save()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (p *jobProgress) save() {
+	p.mu.Lock()
+	summary := p.summary
+	p.mu.Unlock()
+
+	cancelRequested, err := p.repo.SaveImportJobProgress(p.ctx, p.id, summary)
+	if err != nil {
+		slog.WarnContext(p.ctx, "error to save import job progress", "job", p.id, "error", err)
+		return
+	}
+	if cancelRequested {
+		p.cancel(errJobCanceled)
+	}
+}
@@ -0,0 +1,189 @@
+//go:build unit
+
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportJobs(t *testing.T) {
+	t.Run("job should import the file and record its outcome", func(t *testing.T) {
+		ctx := domain.WithChangeSource(context.Background(), domain.ChangeSource{Kind: domain.ChangeSourceHTTP, Actor: "ops"})
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		id := "AEAJM"
+		port := domain.Port{ID: &id, Unlocs: []string{"AEAJM"}}
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error)
+		portCh <- port
+		close(portCh)
+
+		var jobID string
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("CreateImportJob", mock.Anything, mock.MatchedBy(func(job domain.ImportJob) bool {
+			jobID = job.ID
+			return job.Status == domain.ImportJobRunning && job.Format == "json" && job.Source == "ports.json"
+		})).Return(nil).Once()
+		repoMock.On("FindImportJob", mock.Anything, mock.Anything).Return(&domain.ImportJob{Status: domain.ImportJobRunning}, nil).Once()
+		repoMock.On("SaveBulk", mock.MatchedBy(func(ctx context.Context) bool {
+			source := domain.ChangeSourceFromContext(ctx)
+			return source.Kind == domain.ChangeSourceImport && source.Actor == "ops" && source.RunID == jobID
+		}), []domain.Port{port}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("CreateImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.ID == jobID && run.Source == "ports.json" && run.Checksum == "sha256:abc" && run.Outcome == domain.ImportRunning
+		})).Return(nil).Once()
+		repoMock.On("SaveImportJobProgress", mock.Anything, mock.Anything, mock.Anything).Return(false, nil).Maybe()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.ID == jobID && run.Outcome == domain.ImportSucceeded && run.Summary.Imported == 1
+		})).Return(nil).Once()
+		finished := make(chan domain.ImportJob, 1)
+		repoMock.On("FinishImportJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			finished <- args.Get(1).(domain.ImportJob)
+		}).Return(nil).Once()
+
+		released := false
+		jobs := NewImportJobs(repoMock)
+		job, err := jobs.StartImportJob(ctx, domain.ImportJobRequest{
+			Format:   "json",
+			Source:   "ports.json",
+			Checksum: "sha256:abc",
+			Parser:   parserMock,
+			Release:  func() { released = true },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportJobRunning, job.Status)
+
+		recorded := <-finished
+		assert.Equal(t, jobID, recorded.ID)
+		assert.Equal(t, domain.ImportJobSucceeded, recorded.Status)
+		assert.Equal(t, domain.ImportSummary{Parsed: 1, Imported: 1}, recorded.Summary)
+		assert.Empty(t, recorded.Error)
+
+		jobs.Close()
+		assert.True(t, released)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("canceled job should stop and be recorded as canceled", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		// the file never ends, only the cancellation stops the job
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(make(chan domain.Port)), (<-chan error)(make(chan error)))
+		var jobID string
+		repoMock.On("CreateImportJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			jobID = args.Get(1).(domain.ImportJob).ID
+		}).Return(nil).Once()
+		repoMock.On("FindImportJob", mock.Anything, mock.Anything).Return(&domain.ImportJob{Status: domain.ImportJobRunning}, nil)
+		repoMock.On("RequestImportJobCancel", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("CreateImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("SaveImportJobProgress", mock.Anything, mock.Anything, mock.Anything).Return(false, nil).Maybe()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.Outcome == domain.ImportCanceled
+		})).Return(nil).Once()
+		finished := make(chan domain.ImportJob, 1)
+		repoMock.On("FinishImportJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			finished <- args.Get(1).(domain.ImportJob)
+		}).Return(nil).Once()
+
+		jobs := NewImportJobs(repoMock)
+		_, err := jobs.StartImportJob(ctx, domain.ImportJobRequest{Format: "json", Parser: parserMock})
+		require.NoError(t, err)
+		_, err = jobs.CancelImportJob(ctx, jobID)
+		require.NoError(t, err)
+
+		recorded := <-finished
+		assert.Equal(t, domain.ImportJobCanceled, recorded.Status)
+		assert.Equal(t, errJobCanceled.Error(), recorded.Error)
+		jobs.Close()
+	})
+
+	t.Run("close should cancel the running jobs", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(make(chan domain.Port)), (<-chan error)(make(chan error)))
+		repoMock.On("CreateImportJob", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("FindImportJob", mock.Anything, mock.Anything).Return(&domain.ImportJob{Status: domain.ImportJobRunning}, nil).Once()
+		repoMock.On("CreateImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("SaveImportJobProgress", mock.Anything, mock.Anything, mock.Anything).Return(false, nil).Maybe()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.Outcome == domain.ImportCanceled && run.Error == errServerStopped.Error()
+		})).Return(nil).Once()
+		repoMock.On("FinishImportJob", mock.Anything, mock.MatchedBy(func(job domain.ImportJob) bool {
+			return job.Status == domain.ImportJobCanceled && job.Error == errServerStopped.Error()
+		})).Return(nil).Once()
+
+		jobs := NewImportJobs(repoMock)
+		_, err := jobs.StartImportJob(ctx, domain.ImportJobRequest{Format: "json", Parser: parserMock})
+		require.NoError(t, err)
+
+		jobs.Close()
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("running job should save its progress and stop when another server requests its cancellation", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		// the file never ends, only the cancellation stops the job
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(make(chan domain.Port)), (<-chan error)(make(chan error)))
+		repoMock.On("CreateImportJob", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("FindImportJob", mock.Anything, mock.Anything).Return(&domain.ImportJob{Status: domain.ImportJobRunning}, nil).Once()
+		repoMock.On("CreateImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("SaveImportJobProgress", mock.Anything, mock.Anything, domain.ImportSummary{}).Return(false, nil).Once()
+		repoMock.On("SaveImportJobProgress", mock.Anything, mock.Anything, domain.ImportSummary{}).Return(true, nil).Once()
+		repoMock.On("FinishImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+		finished := make(chan domain.ImportJob, 1)
+		repoMock.On("FinishImportJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			finished <- args.Get(1).(domain.ImportJob)
+		}).Return(nil).Once()
+
+		jobs := NewImportJobs(repoMock)
+		jobs.(*importJobs).progressInterval = 10 * time.Millisecond
+		_, err := jobs.StartImportJob(ctx, domain.ImportJobRequest{Format: "json", Parser: parserMock})
+		require.NoError(t, err)
+
+		recorded := <-finished
+		assert.Equal(t, domain.ImportJobCanceled, recorded.Status)
+		assert.Equal(t, errJobCanceled.Error(), recorded.Error)
+		jobs.Close()
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("recover should finish the jobs that stopped saving their progress", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+
+		repoMock.On("FinishStaleImportJobs", mock.Anything, mock.MatchedBy(func(staleSince time.Time) bool {
+			return time.Since(staleSince) >= staleJobAge && time.Since(staleSince) < staleJobAge+time.Minute
+		}), errJobAbandoned.Error()).Return(int64(2), nil).Once()
+
+		jobs := NewImportJobs(repoMock)
+		require.NoError(t, jobs.RecoverImportJobs(ctx))
+		jobs.Close()
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("cancel of a finished job should return error", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+
+		repoMock.On("RequestImportJobCancel", mock.Anything, "abc").Return(domain.ErrImportJobFinished).Once()
+
+		_, err := NewImportJobs(repoMock).CancelImportJob(ctx, "abc")
+		assert.ErrorIs(t, err, domain.ErrImportJobFinished)
+	})
+}
@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// RunImport records run, then imports the ports like ImportPorts with the run ID as the run of the change
+// source, and records the outcome. The ID of run is generated when it is empty. With options.SkipIfUnchanged
+// nothing is imported when the last import read a file of the same checksum in the same mode and
+// succeeded: the run is recorded as domain.ImportUnchanged. The checksum and size of options.Digest are
+// recorded with the outcome.
+func (s *service) RunImport(ctx context.Context, run domain.ImportRun, options domain.ImportOptions) (*domain.ImportRun, error) {
+	if run.ID == "" {
+		id, err := newImportID()
+		if err != nil {
+			return nil, err
+		}
+		run.ID = id
+	}
+	run.Mode, run.DryRun = options.Mode.String(), options.DryRun
+	run.Outcome, run.StartedAt = domain.ImportRunning, time.Now().UTC()
+
+	unchanged, err := s.unchanged(ctx, run, options)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		run.Outcome = domain.ImportUnchanged
+	}
+	if err := s.repo.CreateImportRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "error to record import run", "run", run.ID, "error", err)
+		return nil, err
+	}
+	if unchanged {
+		slog.InfoContext(ctx, "file unchanged since the last import, skipping it", "run", run.ID, "checksum", run.Checksum)
+		s.finishRun(ctx, &run)
+		return &run, nil
+	}
+
+	source := domain.ChangeSourceFromContext(ctx)
+	source.RunID = run.ID
+	summary, err := s.ImportPorts(domain.WithChangeSource(ctx, source), options)
+	if summary != nil {
+		run.Summary = *summary
+	}
+
+	if err == nil && options.Digest != nil {
+		if run.Checksum, run.Size, err = options.Digest.Digest(); err != nil {
+			err = fmt.Errorf("error reading the rest of the source: %w", err)
+		}
+	}
+
+	switch {
+	case err == nil:
+		run.Outcome = domain.ImportSucceeded
+	case ctx.Err() != nil:
+		run.Outcome, run.Error = domain.ImportCanceled, context.Cause(ctx).Error()
+	default:
+		run.Outcome, run.Error = domain.ImportFailed, err.Error()
+	}
+	s.finishRun(ctx, &run)
+
+	return &run, err
+}
+
+// unchanged tells whether the file of run was the last one imported, in the same mode and with success.
+func (s *service) unchanged(ctx context.Context, run domain.ImportRun, options domain.ImportOptions) (bool, error) {
+	if !options.SkipIfUnchanged || run.Checksum == "" {
+		return false, nil
+	}
+
+	last, err := s.repo.FindLastImportRun(ctx)
+	if errors.Is(err, domain.ErrImportRunNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error to find the last import run", "error", err)
+		return false, err
+	}
+
+	return last.Outcome == domain.ImportSucceeded && last.Checksum == run.Checksum && last.Mode == run.Mode, nil
+}
+
+// finishRun records the outcome of run, even when the import was canceled. The import is over,
+// a failure to record it is only logged.
+func (s *service) finishRun(ctx context.Context, run *domain.ImportRun) {
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+
+	if err := s.repo.FinishImportRun(context.WithoutCancel(ctx), *run); err != nil {
+		slog.ErrorContext(ctx, "error to record import run outcome", "run", run.ID, "outcome", run.Outcome, "error", err)
+	}
+}
+
+func (s *service) ImportRuns(ctx context.Context, limit int) ([]domain.ImportRun, error) {
+	if limit < 0 || limit > maxRunsLimit {
+		return nil, fmt.Errorf("%w: limit must be within [0, %d], 0 for the default", domain.ErrInvalidQuery, maxRunsLimit)
+	}
+	if limit == 0 {
+		limit = defaultRunsLimit
+	}
+
+	runs, err := s.repo.ListImportRuns(ctx, limit)
+	if err != nil {
+		slog.Error("error to list import runs", "error", err)
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// newImportID identifies the import runs, and the jobs running them.
+func newImportID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,145 @@
+//go:build unit
+
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunImport(t *testing.T) {
+	id := "AEAJM"
+	port := domain.Port{ID: &id, Unlocs: []string{"AEAJM"}}
+	parserOf := func(ports ...domain.Port) *mocks.ParserPort {
+		portCh := make(chan domain.Port, len(ports))
+		for _, port := range ports {
+			portCh <- port
+		}
+		close(portCh)
+
+		parserMock := &mocks.ParserPort{}
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(make(chan error)))
+		return parserMock
+	}
+
+	t.Run("run should import the file and record the run", func(t *testing.T) {
+		ctx := domain.WithChangeSource(context.Background(), domain.ChangeSource{Kind: domain.ChangeSourceImport, Actor: "ports.json"})
+		repoMock := &mocks.RepositoryPort{}
+
+		var runID string
+		repoMock.On("CreateImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			runID = run.ID
+			return run.ID != "" && run.Source == "ports.json" && run.Mode == "sync" && run.Size == 42 &&
+				run.Checksum == "sha256:abc" && run.Outcome == domain.ImportRunning && !run.StartedAt.IsZero()
+		})).Return(nil).Once()
+		repoMock.On("SaveBulk", mock.MatchedBy(func(ctx context.Context) bool {
+			source := domain.ChangeSourceFromContext(ctx)
+			return source.Actor == "ports.json" && source.RunID == runID
+		}), []domain.Port{port}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("MissingIDs", mock.Anything, []string{id}).Return([]string{}, nil).Once()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.ID == runID && run.Outcome == domain.ImportSucceeded && run.FinishedAt != nil &&
+				run.Summary == domain.ImportSummary{Parsed: 1, Imported: 1}
+		})).Return(nil).Once()
+
+		service := NewService(repoMock, parserOf(port))
+		run, err := service.RunImport(ctx, domain.ImportRun{Source: "ports.json", Format: "json", Size: 42, Checksum: "sha256:abc"},
+			domain.ImportOptions{Mode: domain.ImportSync, MaxDeletePercent: 10})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSucceeded, run.Outcome)
+		assert.Equal(t, runID, run.ID)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("failed run should be recorded with its error", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+
+		failure := errors.New("connection refused")
+		repoMock.On("CreateImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, mock.Anything).Return(domain.SaveResult{}, failure).Once()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.ID == "abc" && run.Outcome == domain.ImportFailed && run.Error != "" && run.Summary.Parsed == 1
+		})).Return(nil).Once()
+
+		service := NewService(repoMock, parserOf(port))
+		run, err := service.RunImport(ctx, domain.ImportRun{ID: "abc", Source: "ports.json"}, domain.ImportOptions{})
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, domain.ImportFailed, run.Outcome)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("run of the standard input should record its digest once imported", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		digestMock := &mocks.DigestPort{}
+
+		repoMock.On("CreateImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.Checksum == "" && run.Size == 0
+		})).Return(nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, mock.Anything).Return(domain.SaveResult{}, nil).Once()
+		digestMock.On("Digest").Return("sha256:abc", int64(42), nil).Once()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.Outcome == domain.ImportSucceeded && run.Checksum == "sha256:abc" && run.Size == 42
+		})).Return(nil).Once()
+
+		service := NewService(repoMock, parserOf(port))
+		run, err := service.RunImport(ctx, domain.ImportRun{Source: "stdin"}, domain.ImportOptions{Digest: digestMock})
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:abc", run.Checksum)
+		repoMock.AssertExpectations(t)
+		digestMock.AssertExpectations(t)
+	})
+
+	t.Run("unchanged file should be recorded and not imported", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		repoMock.On("FindLastImportRun", mock.Anything).Return(&domain.ImportRun{
+			Mode: "upsert", Checksum: "sha256:abc", Outcome: domain.ImportSucceeded,
+		}, nil).Once()
+		repoMock.On("CreateImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.Outcome == domain.ImportUnchanged
+		})).Return(nil).Once()
+		repoMock.On("FinishImportRun", mock.Anything, mock.MatchedBy(func(run domain.ImportRun) bool {
+			return run.Outcome == domain.ImportUnchanged && run.FinishedAt != nil
+		})).Return(nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		run, err := service.RunImport(ctx, domain.ImportRun{Source: "ports.json", Checksum: "sha256:abc"}, domain.ImportOptions{SkipIfUnchanged: true})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportUnchanged, run.Outcome)
+		parserMock.AssertNotCalled(t, "Parse", mock.Anything)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("file should be imported again when the last import failed or ran in another mode", func(t *testing.T) {
+		for _, last := range []domain.ImportRun{
+			{Mode: "upsert", Checksum: "sha256:abc", Outcome: domain.ImportFailed},
+			{Mode: "sync", Checksum: "sha256:abc", Outcome: domain.ImportSucceeded},
+			{Mode: "upsert", Checksum: "sha256:def", Outcome: domain.ImportSucceeded},
+		} {
+			ctx := context.Background()
+			repoMock := &mocks.RepositoryPort{}
+
+			repoMock.On("FindLastImportRun", mock.Anything).Return(&last, nil).Once()
+			repoMock.On("CreateImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+			repoMock.On("SaveBulk", mock.Anything, []domain.Port{port}).Return(domain.SaveResult{}, nil).Once()
+			repoMock.On("FinishImportRun", mock.Anything, mock.Anything).Return(nil).Once()
+
+			service := NewService(repoMock, parserOf(port))
+			run, err := service.RunImport(ctx, domain.ImportRun{Source: "ports.json", Checksum: "sha256:abc"}, domain.ImportOptions{SkipIfUnchanged: true})
+			require.NoError(t, err)
+			assert.Equal(t, domain.ImportSucceeded, run.Outcome, last)
+			repoMock.AssertExpectations(t)
+		}
+	})
+}
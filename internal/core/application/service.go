@@ -2,11 +2,14 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/core/domain/iso3166"
 )
 
 type service struct {
@@ -14,7 +17,21 @@ type service struct {
 	parser domain.ParserPort
 }
 
-const batchSize = 200
+const (
+	defaultNearbyLimit = 20
+	maxNearbyLimit     = 100
+	maxNearbyRadiusKm  = 20038 // half of the earth circumference
+	defaultListLimit   = 50
+	maxListLimit       = 500
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchTermSize  = 100
+	defaultRunsLimit   = 20
+	maxRunsLimit       = 100
+	backfillPageSize   = 500
+)
+
+var sortableFields = map[string]bool{"id": true, "name": true, "city": true, "country": true, "code": true}
 
 func NewService(repo domain.RepositoryPort, parser domain.ParserPort) domain.ServicePort {
 	return &service{repo: repo, parser: parser}
@@ -22,61 +39,83 @@ func NewService(repo domain.RepositoryPort, parser domain.ParserPort) domain.Ser
 
 func (s *service) CreateOrUpdate(ctx context.Context, port domain.Port) error {
 	if len(port.Unlocs) == 0 {
-		return domain.ErrInvalidPort
+		return port.Validate()
 	}
-	portID := strings.ToUpper(port.Unlocs[0])
+	normalizePort(&port)
+	portID := port.Unlocs[0]
 	port.ID = &portID
 
-	return s.repo.SaveBulk(ctx, []domain.Port{port})
+	if err := port.Validate(); err != nil {
+		return err
+	}
+
+	result, err := s.repo.SaveBulk(ctx, []domain.Port{port})
+	if err != nil {
+		return err
+	}
+	if result.Skipped > 0 {
+		return domain.ErrPortDeleted
+	}
+	return nil
 }
 
-func (s *service) ImportPorts(ctx context.Context) error {
-	portCh, errCh := s.parser.Parse(ctx) // pipeline pattern
-	var batch []domain.Port
-	for {
-		select {
-		case <-ctx.Done():
-			// Save items that remains on the batch
-			if len(batch) > 0 {
-				if err := s.repo.SaveBulk(ctx, batch); err != nil {
-					return err
-				}
-			}
+// UpdateIfMatch updates an existing port only if it is still at the given version, see domain.AnyVersion.
+// It returns the new version of the port.
+func (s *service) UpdateIfMatch(ctx context.Context, port domain.Port, version int64) (int64, error) {
+	if len(port.Unlocs) == 0 {
+		return 0, port.Validate()
+	}
+	normalizePort(&port)
+	portID := port.Unlocs[0]
+	port.ID = &portID
 
-			return ctx.Err()
-		case port, ok := <-portCh:
-			if !ok {
-				if len(batch) > 0 {
-					// Save items that remains on the batch
-					if err := s.repo.SaveBulk(ctx, batch); err != nil {
-						return err
-					}
-				}
-				return nil
-			}
+	if err := port.Validate(); err != nil {
+		return 0, err
+	}
 
-			batch = append(batch, port)
+	if version == domain.AnyVersion {
+		current, err := s.repo.FindByID(ctx, portID)
+		if err != nil {
+			return 0, err
+		}
+		version = current.Version
+	}
 
-			if len(batch) == batchSize {
-				// Save items as batch
-				if err := s.repo.SaveBulk(ctx, batch); err != nil {
-					return err
-				}
-				batch = nil
-			}
+	return s.repo.SaveIfVersion(ctx, port, version)
+}
 
-		case err, ok := <-errCh:
-			if ok && err != nil {
-				if len(batch) > 0 {
-					// Save items that remains on the batch
-					if saveErr := s.repo.SaveBulk(ctx, batch); saveErr != nil {
-						return fmt.Errorf("error to save batch: %v; error: %w", saveErr, err)
-					}
-				}
-				return err
-			}
-		}
+// Patch applies the patch to the stored port. The ID cannot be patched and the result must be valid.
+// A non-zero version must match the stored one; the write itself is always conditional, so
+// concurrent changes between the read and the write are never lost.
+func (s *service) Patch(ctx context.Context, portID string, patch domain.Patch, version int64) (*domain.Port, error) {
+	current, err := s.repo.FindByIdentifier(ctx, portID)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != domain.AnyVersion && version != current.Version {
+		return nil, domain.ErrVersionConflict
+	}
+
+	patched, err := patch.Apply(*current)
+	if err != nil {
+		return nil, err
+	}
+	if patched.ID == nil || *patched.ID != *current.ID {
+		return nil, fmt.Errorf("%w: id cannot be changed", domain.ErrInvalidPatch)
 	}
+	normalizePort(&patched)
+
+	if err := patched.Validate(); err != nil {
+		return nil, err
+	}
+
+	patched.Version, err = s.repo.SaveIfVersion(ctx, patched, current.Version)
+	if err != nil {
+		slog.Error("error to save patched port", "error", err)
+		return nil, err
+	}
+
+	return &patched, nil
 }
 
 func (s *service) FindByID(ctx context.Context, portID string) (*domain.Port, error) {
@@ -88,3 +127,276 @@ func (s *service) FindByID(ctx context.Context, portID string) (*domain.Port, er
 
 	return port, nil
 }
+
+// Resolve finds a port by its ID, by any of its UN/LOCODEs or by one of its aliases. The returned port carries the canonical ID.
+func (s *service) Resolve(ctx context.Context, identifier string) (*domain.Port, error) {
+	port, err := s.repo.FindByIdentifier(ctx, identifier)
+	if err != nil {
+		slog.Error("error to resolve a port", "error", err)
+		return nil, err
+	}
+
+	return port, nil
+}
+
+// FindByCode finds the ports with the given code, a code may be shared by several ports.
+func (s *service) FindByCode(ctx context.Context, code string) ([]domain.Port, error) {
+	ports, err := s.repo.FindByCode(ctx, code)
+	if err != nil {
+		slog.Error("error to find ports by code", "error", err)
+		return nil, err
+	}
+	if len(ports) == 0 {
+		return nil, domain.ErrPortNotFound
+	}
+
+	return ports, nil
+}
+
+func (s *service) FindNearby(ctx context.Context, query domain.NearbyQuery) ([]domain.NearbyPort, error) {
+	if query.Latitude < -90 || query.Latitude > 90 {
+		return nil, fmt.Errorf("%w: latitude must be within [-90, 90]", domain.ErrInvalidQuery)
+	}
+	if query.Longitude < -180 || query.Longitude > 180 {
+		return nil, fmt.Errorf("%w: longitude must be within [-180, 180]", domain.ErrInvalidQuery)
+	}
+	if query.RadiusKm <= 0 || query.RadiusKm > maxNearbyRadiusKm {
+		return nil, fmt.Errorf("%w: radius_km must be within (0, %d]", domain.ErrInvalidQuery, maxNearbyRadiusKm)
+	}
+	if query.Limit < 0 || query.Limit > maxNearbyLimit {
+		return nil, fmt.Errorf("%w: limit must be within [0, %d], 0 for the default", domain.ErrInvalidQuery, maxNearbyLimit)
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultNearbyLimit
+	}
+
+	ports, err := s.repo.FindNearby(ctx, query)
+	if err != nil {
+		slog.Error("error to find nearby ports", "error", err)
+		return nil, err
+	}
+
+	return ports, nil
+}
+
+func (s *service) List(ctx context.Context, query domain.ListQuery) (*domain.PortPage, error) {
+	if query.Sort == "" {
+		query.Sort = "id"
+	}
+	if !sortableFields[strings.TrimPrefix(query.Sort, "-")] {
+		return nil, fmt.Errorf("%w: sort must be one of id, name, city, country or code", domain.ErrInvalidQuery)
+	}
+	if query.Limit < 0 || query.Limit > maxListLimit {
+		return nil, fmt.Errorf("%w: limit must be within [0, %d], 0 for the default", domain.ErrInvalidQuery, maxListLimit)
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultListLimit
+	}
+	if query.CountryCode != "" {
+		country, ok := iso3166.CountryByCode(query.CountryCode)
+		if !ok {
+			return nil, domain.ErrCountryNotFound
+		}
+		query.CountryCode = country.Alpha2
+	}
+
+	page, err := s.repo.List(ctx, query)
+	if err != nil {
+		slog.Error("error to list ports", "error", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (s *service) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	query.Term = strings.TrimSpace(query.Term)
+	if query.Term == "" {
+		return nil, fmt.Errorf("%w: missing search term", domain.ErrInvalidQuery)
+	}
+	if len(query.Term) > maxSearchTermSize {
+		return nil, fmt.Errorf("%w: search term longer than %d bytes", domain.ErrInvalidQuery, maxSearchTermSize)
+	}
+	if query.Limit < 0 || query.Limit > maxSearchLimit {
+		return nil, fmt.Errorf("%w: limit must be within [0, %d], 0 for the default", domain.ErrInvalidQuery, maxSearchLimit)
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSearchLimit
+	}
+
+	results, err := s.repo.Search(ctx, query)
+	if err != nil {
+		slog.Error("error to search ports", "error", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *service) History(ctx context.Context, portID string) ([]domain.PortChange, error) {
+	portID, live, err := s.canonicalID(ctx, portID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.repo.History(ctx, portID)
+	if err != nil {
+		slog.Error("error to get port history", "error", err)
+		return nil, err
+	}
+	if len(changes) == 0 {
+		if !live {
+			return nil, domain.ErrPortNotFound
+		}
+		// ports stored before the history was recorded have none yet
+		return []domain.PortChange{}, nil
+	}
+
+	return changes, nil
+}
+
+// Countries lists every ISO 3166-1 country with the number of ports it has.
+func (s *service) Countries(ctx context.Context) ([]domain.Country, error) {
+	counts, err := s.repo.CountByCountry(ctx)
+	if err != nil {
+		slog.Error("error to count ports by country", "error", err)
+		return nil, err
+	}
+
+	all := iso3166.Countries()
+	countries := make([]domain.Country, 0, len(all))
+	for _, c := range all {
+		countries = append(countries, domain.Country{Code: c.Alpha2, Alpha3: c.Alpha3, Name: c.Name, Ports: counts[c.Alpha2]})
+	}
+
+	return countries, nil
+}
+
+func (s *service) Delete(ctx context.Context, portID string, version int64) error {
+	portID, _, err := s.canonicalID(ctx, portID)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 || version == domain.AnyVersion {
+		err = s.repo.Delete(ctx, portID)
+	} else {
+		err = s.repo.DeleteIfVersion(ctx, portID, version)
+	}
+	if err != nil {
+		slog.Error("error to delete a port", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) Restore(ctx context.Context, portID string, version int64) error {
+	var err error
+	if version == 0 || version == domain.AnyVersion {
+		err = s.repo.Restore(ctx, portID)
+	} else {
+		err = s.repo.RestoreIfVersion(ctx, portID, version)
+	}
+	if err != nil {
+		slog.Error("error to restore a port", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// canonicalID resolves a reference to a port, as Resolve does, to its ID and tells whether it names a live port.
+// A reference naming no live port is returned as it is: deleted ports are only known by their ID.
+func (s *service) canonicalID(ctx context.Context, identifier string) (string, bool, error) {
+	port, err := s.repo.FindByIdentifier(ctx, identifier)
+	if err != nil {
+		if errors.Is(err, domain.ErrPortNotFound) {
+			return identifier, false, nil
+		}
+		slog.Error("error to resolve a port", "error", err)
+		return "", false, err
+	}
+
+	return *port.ID, true, nil
+}
+
+// Purge removes for good the ports deleted more than olderThan ago.
+func (s *service) Purge(ctx context.Context, olderThan time.Duration) (int64, error) {
+	if olderThan < 0 {
+		return 0, fmt.Errorf("%w: age must not be negative", domain.ErrInvalidQuery)
+	}
+
+	purged, err := s.repo.Purge(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		slog.Error("error to purge ports", "error", err)
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+func (s *service) BackfillCountryCodes(ctx context.Context) (int64, error) {
+	query := domain.ListQuery{Limit: backfillPageSize}
+	var saved int64
+	for {
+		page, err := s.repo.List(ctx, query)
+		if err != nil {
+			slog.Error("error to list ports", "error", err)
+			return saved, err
+		}
+
+		for _, port := range page.Ports {
+			resolved := port
+			resolved.ResolveCountry()
+			if resolved.CountryCode == port.CountryCode && resolved.SubdivisionCode == port.SubdivisionCode {
+				continue
+			}
+
+			_, err := s.repo.SaveIfVersion(ctx, resolved, port.Version)
+			switch {
+			case err == nil:
+				saved++
+			case errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrPortNotFound):
+				// written or deleted meanwhile, a write resolves the codes itself
+				slog.Info("port changed during the backfill, skipping it", "id", *port.ID)
+			default:
+				slog.Error("error to save port codes", "id", *port.ID, "error", err)
+				return saved, err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return saved, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// normalizePort prepares a port coming from a client or an import file to be validated and stored.
+func normalizePort(port *domain.Port) {
+	normalizeUnlocs(port)
+	port.ResolveCountry()
+}
+
+// normalizeUnlocs upper-cases the UN/LOCODEs, they are matched case-insensitively on lookups.
+func normalizeUnlocs(port *domain.Port) {
+	unlocs := make([]string, len(port.Unlocs))
+	for i, unloc := range port.Unlocs {
+		unlocs[i] = strings.ToUpper(unloc)
+	}
+	port.Unlocs = unlocs
+}
+
+func portKey(port domain.Port) string {
+	if port.ID != nil {
+		return *port.ID
+	}
+	if len(port.Unlocs) > 0 {
+		return port.Unlocs[0]
+	}
+	return unknownPortKey
+}
+
+// unknownPortKey is the key of a port with neither ID nor UN/LOCODE.
+const unknownPortKey = "<unknown>"
@@ -87,7 +87,7 @@ func TestIntegrationService(t *testing.T) {
 		p := parser.NewJSONParser(reader)
 		s := NewService(repo, p)
 
-		err := s.ImportPorts(ctx)
+		_, err := s.ImportPorts(ctx, domain.ImportOptions{})
 		assert.NoError(t, err)
 
 		portResponse, err := s.FindByID(ctx, "AEAJM")
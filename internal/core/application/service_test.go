@@ -5,11 +5,15 @@ package application
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/guil95/ports-service/internal/core/domain"
 	"github.com/guil95/ports-service/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService(t *testing.T) {
@@ -34,12 +38,24 @@ func TestService(t *testing.T) {
 
 		portToSave := port
 		portToSave.ID = &port.Unlocs[0]
-		repoMock.On("SaveBulk", ctx, []domain.Port{portToSave}).Return(nil)
+		portToSave.CountryCode, portToSave.SubdivisionCode = "CN", "CN-JS"
+		repoMock.On("SaveBulk", ctx, []domain.Port{portToSave}).Return(domain.SaveResult{}, nil)
 
 		err := portsService.CreateOrUpdate(ctx, port)
 		assert.NoError(t, err)
 	})
 
+	t.Run("create deleted port should not restore it", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("SaveBulk", ctx, mock.Anything).Return(domain.SaveResult{Skipped: 1}, nil)
+
+		err := portsService.CreateOrUpdate(ctx, domain.Port{Name: "Dubai", Unlocs: []string{"AEDXB"}})
+		assert.ErrorIs(t, err, domain.ErrPortDeleted)
+	})
+
 	t.Run("create port with error", func(t *testing.T) {
 		repoMock := new(mocks.RepositoryPort)
 		parserMock := new(mocks.ParserPort)
@@ -62,13 +78,36 @@ func TestService(t *testing.T) {
 		repoError := errors.New("internal error")
 		portToSave := port
 		portToSave.ID = &port.Unlocs[0]
-		repoMock.On("SaveBulk", ctx, []domain.Port{portToSave}).Return(repoError)
+		portToSave.CountryCode, portToSave.SubdivisionCode = "CN", "CN-JS"
+		repoMock.On("SaveBulk", ctx, []domain.Port{portToSave}).Return(domain.SaveResult{}, repoError)
 
 		err := portsService.CreateOrUpdate(ctx, port)
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, repoError)
 	})
 
+	t.Run("create invalid port should return validation error", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		parserMock := new(mocks.ParserPort)
+		portsService := NewService(repoMock, parserMock)
+		ctx := context.Background()
+
+		port := domain.Port{
+			Name:        "China",
+			Coordinates: []float64{120.752503},
+			Timezone:    "Asia/Nowhere",
+			Unlocs:      []string{"CNCGU"},
+		}
+
+		err := portsService.CreateOrUpdate(ctx, port)
+		assert.ErrorIs(t, err, domain.ErrInvalidPort)
+
+		var verr *domain.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.Len(t, verr.Fields, 2)
+		repoMock.AssertNotCalled(t, "SaveBulk")
+	})
+
 	t.Run("find port by ID", func(t *testing.T) {
 		repoMock := new(mocks.RepositoryPort)
 		parserMock := new(mocks.ParserPort)
@@ -112,6 +151,448 @@ func TestService(t *testing.T) {
 	})
 }
 
+type patchFunc func(port domain.Port) (domain.Port, error)
+
+func (f patchFunc) Apply(port domain.Port) (domain.Port, error) {
+	return f(port)
+}
+
+func TestPatch(t *testing.T) {
+	newPort := func() *domain.Port {
+		id := "CNCGU"
+		return &domain.Port{ID: &id, Name: "China", Timezone: "Asia/Shanghai", Unlocs: []string{"CNCGU"}, Version: 4}
+	}
+
+	t.Run("patch port with success", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		expected := *newPort()
+		expected.Name = "Changshu"
+		repoMock.On("FindByIdentifier", ctx, "CNCGU").Return(newPort(), nil)
+		repoMock.On("SaveIfVersion", ctx, expected, int64(4)).Return(int64(5), nil)
+
+		patched, err := portsService.Patch(ctx, "CNCGU", patchFunc(func(port domain.Port) (domain.Port, error) {
+			port.Name = "Changshu"
+			return port, nil
+		}), 0)
+		assert.NoError(t, err)
+		expected.Version = 5
+		assert.Equal(t, &expected, patched)
+	})
+
+	t.Run("patch with outdated version should return conflict", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "CNCGU").Return(newPort(), nil)
+
+		_, err := portsService.Patch(ctx, "CNCGU", patchFunc(func(port domain.Port) (domain.Port, error) {
+			return port, nil
+		}), 3)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		repoMock.AssertNotCalled(t, "SaveIfVersion")
+	})
+
+	t.Run("patch changed concurrently should return conflict", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "CNCGU").Return(newPort(), nil)
+		repoMock.On("SaveIfVersion", ctx, mock.Anything, int64(4)).Return(int64(0), domain.ErrVersionConflict)
+
+		_, err := portsService.Patch(ctx, "CNCGU", patchFunc(func(port domain.Port) (domain.Port, error) {
+			return port, nil
+		}), 4)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	})
+
+	t.Run("patch resulting in invalid port should not save it", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "CNCGU").Return(newPort(), nil)
+
+		_, err := portsService.Patch(ctx, "CNCGU", patchFunc(func(port domain.Port) (domain.Port, error) {
+			port.Timezone = "Asia/Nowhere"
+			return port, nil
+		}), 0)
+		assert.ErrorIs(t, err, domain.ErrInvalidPort)
+		repoMock.AssertNotCalled(t, "SaveIfVersion")
+	})
+
+	t.Run("patch changing the id should return error", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "CNCGU").Return(newPort(), nil)
+
+		_, err := portsService.Patch(ctx, "CNCGU", patchFunc(func(port domain.Port) (domain.Port, error) {
+			otherID := "CNBJO"
+			port.ID = &otherID
+			return port, nil
+		}), 0)
+		assert.ErrorIs(t, err, domain.ErrInvalidPatch)
+		repoMock.AssertNotCalled(t, "SaveIfVersion")
+	})
+
+	t.Run("patch unknown port should return not found", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "XXXXX").Return(nil, domain.ErrPortNotFound)
+
+		_, err := portsService.Patch(ctx, "XXXXX", patchFunc(func(port domain.Port) (domain.Port, error) {
+			return port, nil
+		}), 0)
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+	})
+}
+
+func TestUpdateIfMatch(t *testing.T) {
+	port := domain.Port{Name: "China", Timezone: "Asia/Shanghai", Unlocs: []string{"cncgu"}}
+	portID := "CNCGU"
+	portToSave := port
+	portToSave.ID = &portID
+	portToSave.Unlocs = []string{"CNCGU"}
+
+	t.Run("update port with matching version", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("SaveIfVersion", ctx, portToSave, int64(2)).Return(int64(3), nil)
+
+		version, err := portsService.UpdateIfMatch(ctx, port, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), version)
+	})
+
+	t.Run("update port with any version requires the port to exist", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByID", ctx, portID).Return(&domain.Port{ID: &portID, Version: 7}, nil)
+		repoMock.On("SaveIfVersion", ctx, portToSave, int64(7)).Return(int64(8), nil)
+
+		version, err := portsService.UpdateIfMatch(ctx, port, domain.AnyVersion)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), version)
+	})
+
+	t.Run("update port with outdated version should return conflict", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("SaveIfVersion", ctx, portToSave, int64(1)).Return(int64(0), domain.ErrVersionConflict)
+
+		_, err := portsService.UpdateIfMatch(ctx, port, 1)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	})
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("resolve port by secondary unloc", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+		id := "CNDLC"
+
+		port := &domain.Port{ID: &id, Unlocs: []string{"CNDLC", "CNDAL"}}
+		repoMock.On("FindByIdentifier", ctx, "CNDAL").Return(port, nil)
+
+		resolved, err := portsService.Resolve(ctx, "CNDAL")
+		assert.NoError(t, err)
+		assert.Equal(t, port, resolved)
+	})
+
+	t.Run("find by unknown code should return not found", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByCode", ctx, "00000").Return([]domain.Port{}, nil)
+
+		ports, err := portsService.FindByCode(ctx, "00000")
+		assert.Nil(t, ports)
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+	})
+
+	t.Run("create port should store upper-cased unlocs", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+		id := "CNDLC"
+
+		repoMock.On("SaveBulk", ctx, []domain.Port{{ID: &id, Unlocs: []string{"CNDLC", "CNDAL"}}}).Return(domain.SaveResult{}, nil)
+
+		err := portsService.CreateOrUpdate(ctx, domain.Port{Unlocs: []string{"cndlc", "CNdal"}})
+		assert.NoError(t, err)
+	})
+}
+
+func TestFindNearby(t *testing.T) {
+	t.Run("find nearby ports with default limit", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+		id := "AEDXB"
+
+		expectedQuery := domain.NearbyQuery{Latitude: 25.2, Longitude: 55.3, RadiusKm: 50, Limit: 20}
+		nearby := []domain.NearbyPort{{Port: domain.Port{ID: &id, Name: "Dubai"}, DistanceKm: 7.4}}
+		repoMock.On("FindNearby", ctx, expectedQuery).Return(nearby, nil)
+
+		ports, err := portsService.FindNearby(ctx, domain.NearbyQuery{Latitude: 25.2, Longitude: 55.3, RadiusKm: 50})
+		assert.NoError(t, err)
+		assert.Equal(t, nearby, ports)
+	})
+
+	t.Run("find nearby ports with invalid query should return error", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		queries := []domain.NearbyQuery{
+			{Latitude: 91, Longitude: 55.3, RadiusKm: 50},
+			{Latitude: 25.2, Longitude: -181, RadiusKm: 50},
+			{Latitude: 25.2, Longitude: 55.3, RadiusKm: 0},
+			{Latitude: 25.2, Longitude: 55.3, RadiusKm: 50, Limit: 101},
+		}
+		for _, query := range queries {
+			_, err := portsService.FindNearby(ctx, query)
+			assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+		}
+		repoMock.AssertNotCalled(t, "FindNearby")
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("list ports with default sort and limit", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+		id := "AEDXB"
+
+		page := &domain.PortPage{Ports: []domain.Port{{ID: &id, Name: "Dubai"}}, NextCursor: "next"}
+		repoMock.On("List", ctx, domain.ListQuery{Country: "United Arab Emirates", Sort: "id", Limit: 50}).Return(page, nil)
+
+		result, err := portsService.List(ctx, domain.ListQuery{Country: "United Arab Emirates"})
+		assert.NoError(t, err)
+		assert.Equal(t, page, result)
+	})
+
+	t.Run("list ports with invalid query should return error", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		_, err := portsService.List(ctx, domain.ListQuery{Sort: "-unlocs"})
+		assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+
+		_, err = portsService.List(ctx, domain.ListQuery{Limit: 501})
+		assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+		repoMock.AssertNotCalled(t, "List")
+	})
+
+	t.Run("list ports by alpha-3 country code should query the alpha-2 code", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		page := &domain.PortPage{Ports: []domain.Port{}}
+		repoMock.On("List", ctx, domain.ListQuery{CountryCode: "AE", Sort: "id", Limit: 50}).Return(page, nil)
+
+		result, err := portsService.List(ctx, domain.ListQuery{CountryCode: "are"})
+		assert.NoError(t, err)
+		assert.Equal(t, page, result)
+	})
+
+	t.Run("list ports by unknown country code should return not found", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+
+		_, err := portsService.List(context.Background(), domain.ListQuery{CountryCode: "ZZ"})
+		assert.ErrorIs(t, err, domain.ErrCountryNotFound)
+		repoMock.AssertNotCalled(t, "List")
+	})
+}
+
+func TestCountries(t *testing.T) {
+	t.Run("list countries with their number of ports", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("CountByCountry", ctx).Return(map[string]int64{"AE": 12}, nil)
+
+		countries, err := portsService.Countries(ctx)
+		assert.NoError(t, err)
+		assert.Contains(t, countries, domain.Country{Code: "AE", Alpha3: "ARE", Name: "United Arab Emirates", Ports: 12})
+		assert.Contains(t, countries, domain.Country{Code: "AD", Alpha3: "AND", Name: "Andorra", Ports: 0})
+	})
+}
+
+func TestSearch(t *testing.T) {
+	t.Run("search ports with trimmed term and default limit", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+		id := "AEAUH"
+
+		results := []domain.SearchResult{{Port: domain.Port{ID: &id, Name: "Abu Dhabi"}, Score: 0.77}}
+		repoMock.On("Search", ctx, domain.SearchQuery{Term: "abu dabi", Limit: 20}).Return(results, nil)
+
+		found, err := portsService.Search(ctx, domain.SearchQuery{Term: "  abu dabi "})
+		assert.NoError(t, err)
+		assert.Equal(t, results, found)
+	})
+
+	t.Run("search ports without term should return error", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+
+		_, err := portsService.Search(context.Background(), domain.SearchQuery{Term: " "})
+		assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+		repoMock.AssertNotCalled(t, "Search")
+	})
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("get port history", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		changes := []domain.PortChange{{
+			PortID:    "AEDXB",
+			Operation: "update",
+			Source:    domain.ChangeSourceHTTP,
+			Diff:      map[string]domain.FieldChange{"timezone": {Old: "Asia/Muscat", New: "Asia/Dubai"}},
+		}}
+		aedxb := "AEDXB"
+		repoMock.On("FindByIdentifier", ctx, "aedxb").Return(&domain.Port{ID: &aedxb}, nil)
+		repoMock.On("History", ctx, "AEDXB").Return(changes, nil)
+
+		history, err := portsService.History(ctx, "aedxb")
+		assert.NoError(t, err)
+		assert.Equal(t, changes, history)
+	})
+
+	t.Run("get history of unknown port should return not found", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "XXXXX").Return(nil, domain.ErrPortNotFound)
+		repoMock.On("History", ctx, "XXXXX").Return([]domain.PortChange{}, nil)
+
+		history, err := portsService.History(ctx, "XXXXX")
+		assert.Nil(t, history)
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+	})
+
+	t.Run("get history of port without changes should return an empty list", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		id := "AEDXB"
+		repoMock.On("FindByIdentifier", ctx, id).Return(&domain.Port{ID: &id, Name: "Dubai"}, nil)
+		repoMock.On("History", ctx, id).Return([]domain.PortChange{}, nil)
+
+		history, err := portsService.History(ctx, id)
+		assert.NoError(t, err)
+		assert.NotNil(t, history)
+		assert.Empty(t, history)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("delete and restore port", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "AEDXB").Return(nil, domain.ErrPortNotFound)
+		repoMock.On("Delete", ctx, "AEDXB").Return(nil)
+		repoMock.On("Restore", ctx, "AEDXB").Return(nil)
+
+		assert.NoError(t, portsService.Delete(ctx, "AEDXB", 0))
+		assert.NoError(t, portsService.Restore(ctx, "AEDXB", domain.AnyVersion))
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("delete and restore with a version should require it", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "AEDXB").Return(nil, domain.ErrPortNotFound)
+		repoMock.On("DeleteIfVersion", ctx, "AEDXB", int64(2)).Return(nil)
+		repoMock.On("RestoreIfVersion", ctx, "AEDXB", int64(2)).Return(domain.ErrVersionConflict)
+
+		assert.NoError(t, portsService.Delete(ctx, "AEDXB", 2))
+		assert.ErrorIs(t, portsService.Restore(ctx, "AEDXB", 2), domain.ErrVersionConflict)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("delete unknown port should return not found", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		repoMock.On("FindByIdentifier", ctx, "XXXXX").Return(nil, domain.ErrPortNotFound)
+		repoMock.On("Delete", ctx, "XXXXX").Return(domain.ErrPortNotFound)
+
+		assert.ErrorIs(t, portsService.Delete(ctx, "XXXXX", 0), domain.ErrPortNotFound)
+	})
+
+	t.Run("delete by secondary unloc should delete the canonical port", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		id := "CNDLC"
+		repoMock.On("FindByIdentifier", ctx, "CNDAL").Return(&domain.Port{ID: &id, Unlocs: []string{"CNDLC", "CNDAL"}}, nil)
+		repoMock.On("Delete", ctx, "CNDLC").Return(nil)
+
+		assert.NoError(t, portsService.Delete(ctx, "CNDAL", 0))
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("purge ports deleted before the given age", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		expectedCutoff := time.Now().Add(-24 * time.Hour)
+		repoMock.On("Purge", ctx, mock.MatchedBy(func(deletedBefore time.Time) bool {
+			return deletedBefore.Sub(expectedCutoff).Abs() < time.Minute
+		})).Return(int64(3), nil)
+
+		purged, err := portsService.Purge(ctx, 24*time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+	})
+
+	t.Run("purge with negative age should return error", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+
+		_, err := portsService.Purge(context.Background(), -time.Hour)
+		assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+		repoMock.AssertNotCalled(t, "Purge")
+	})
+}
+
 func TestImports(t *testing.T) {
 	t.Run("import with success", func(t *testing.T) {
 		importData := []domain.Port{
@@ -155,15 +636,17 @@ func TestImports(t *testing.T) {
 		errCh <- nil
 		close(errCh)
 
-		batchToSave := importData
-		batchToSave[0].ID = importData[0].ID
-		batchToSave[1].ID = importData[1].ID
+		batchToSave := make([]domain.Port, len(importData))
+		copy(batchToSave, importData)
+		for i := range batchToSave {
+			batchToSave[i].CountryCode, batchToSave[i].SubdivisionCode = "CN", "CN-JS"
+		}
 
-		parserMock.On("Parse", ctx).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
-		repoMock.On("SaveBulk", ctx, batchToSave).Return(nil).Times(1)
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, batchToSave).Return(domain.SaveResult{}, nil).Times(1)
 
 		service := NewService(repoMock, parserMock)
-		err := service.ImportPorts(ctx)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{})
 		assert.NoError(t, err)
 	})
 
@@ -180,11 +663,681 @@ func TestImports(t *testing.T) {
 		defer close(errCh)
 		errCh <- parseError
 
-		parserMock.On("Parse", ctx).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
 
 		service := NewService(repoMock, parserMock)
-		err := service.ImportPorts(ctx)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{})
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, parseError)
 	})
+
+	t.Run("import with invalid port should not save it", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		validID, invalidID := "CNCGU", "XXXXX"
+		valid := domain.Port{ID: &validID, Name: "China", Unlocs: []string{"CNCGU"}}
+		invalid := domain.Port{ID: &invalidID, Name: "Invalid", Unlocs: []string{"XX"}}
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- valid
+		portCh <- invalid
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{valid}).Return(domain.SaveResult{}, nil).Times(1)
+
+		service := NewService(repoMock, parserMock)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{})
+		assert.ErrorIs(t, err, domain.ErrInvalidPort)
+		assert.ErrorContains(t, err, invalidID)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("import with unloc of another country should fail", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error)
+		portCh <- domain.Port{Name: "Sohar", Country: "United Arab Emirates", Unlocs: []string{"OMSOH"}}
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+
+		service := NewService(repoMock, parserMock)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{})
+		assert.ErrorIs(t, err, domain.ErrInvalidPort)
+		repoMock.AssertNotCalled(t, "SaveBulk")
+	})
+
+	t.Run("import skipping errors should reject bad records and go on", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+		rejectsMock := &mocks.RejectPort{}
+
+		validID, invalidID := "CNCGU", "XXXXX"
+		valid := domain.Port{ID: &validID, Name: "China", Unlocs: []string{"CNCGU"}}
+		invalid := domain.Port{ID: &invalidID, Name: "Invalid", Unlocs: []string{"XX"}, Offset: 120}
+		decodeErr := &domain.RecordError{Key: "line 2", Offset: 60, Err: domain.ErrInvalidJson}
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error, 1)
+		portCh <- invalid
+		portCh <- valid
+		close(portCh)
+		errCh <- decodeErr
+		close(errCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{valid}).Return(domain.SaveResult{}, nil).Times(1)
+		rejectsMock.On("Reject", mock.Anything, *decodeErr).Return(nil).Once()
+		rejectsMock.On("Reject", mock.Anything, mock.MatchedBy(func(record domain.RecordError) bool {
+			return record.Key == invalidID && record.Offset == 120 && errors.Is(record.Err, domain.ErrInvalidPort)
+		})).Return(nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{
+			OnError:   domain.ImportSkip,
+			Rejects:   rejectsMock,
+			MaxErrors: domain.NoErrorLimit,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 3, Imported: 1, Rejected: 2}, *summary)
+		repoMock.AssertExpectations(t)
+		rejectsMock.AssertExpectations(t)
+	})
+
+	t.Run("import skipping errors should fail when the error budget is exceeded", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		portCh := make(chan domain.Port, 3)
+		errCh := make(chan error)
+		for _, unloc := range []string{"XX", "YY", "ZZ"} {
+			portCh <- domain.Port{Unlocs: []string{unloc}}
+		}
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{OnError: domain.ImportSkip, MaxErrors: 1})
+		assert.ErrorIs(t, err, domain.ErrErrorBudgetExceeded)
+		assert.Equal(t, int64(2), summary.Rejected)
+	})
+
+	t.Run("import skipping errors should save a refused batch port by port", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		firstID, secondID := "CNCGU", "CNSHA"
+		first := domain.Port{ID: &firstID, Unlocs: []string{"CNCGU"}}
+		second := domain.Port{ID: &secondID, Unlocs: []string{"CNSHA"}}
+		refused := errors.New("value too long")
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- first
+		portCh <- second
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{first, second}).Return(domain.SaveResult{}, refused).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{first}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{second}).Return(domain.SaveResult{}, refused).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{OnError: domain.ImportSkip, MaxErrors: domain.NoErrorLimit})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 1, Rejected: 1}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("import should count the deleted ports it leaves deleted as skipped", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		liveID, deletedID := "AEAJM", "AEDXB"
+		portCh := make(chan domain.Port, 2)
+		portCh <- domain.Port{ID: &liveID, Name: "Ajman", Unlocs: []string{"AEAJM"}}
+		portCh <- domain.Port{ID: &deletedID, Name: "Dubai", Unlocs: []string{"AEDXB"}}
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(make(chan error)))
+		repoMock.On("SaveBulk", mock.Anything, mock.Anything).Return(domain.SaveResult{Created: 1, Skipped: 1}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 1, Skipped: 1, Created: 1}, *summary)
+	})
+
+	t.Run("dry run should compare the ports with the stored ones and save nothing", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+		diffsMock := &mocks.DiffPort{}
+
+		newID, changedID, sameID, deletedID := "AEAJM", "AEAUH", "AEDXB", "AEFJR"
+		created := domain.Port{ID: &newID, Name: "Ajman", Unlocs: []string{"AEAJM"}}
+		changed := domain.Port{ID: &changedID, Name: "Abu Dhabi", Timezone: "Asia/Dubai", Unlocs: []string{"AEAUH"}}
+		same := domain.Port{ID: &sameID, Name: "Dubai", Unlocs: []string{"AEDXB"}}
+		deleted := domain.Port{ID: &deletedID, Name: "Fujairah", Unlocs: []string{"AEFJR"}}
+
+		portCh := make(chan domain.Port, 4)
+		errCh := make(chan error)
+		portCh <- created
+		portCh <- changed
+		portCh <- same
+		portCh <- deleted
+		close(portCh)
+
+		stored := map[string]domain.Port{
+			changedID: {ID: &changedID, Name: "Abu Dhabi", Timezone: "Asia/Muscat", Unlocs: []string{"AEAUH"}},
+			sameID:    same,
+		}
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		ids := []string{newID, changedID, sameID, deletedID}
+		repoMock.On("FindByIDs", mock.Anything, ids).Return(stored, nil).Once()
+		// the deleted port stays deleted, it is neither created nor reported
+		repoMock.On("DeletedIDs", mock.Anything, ids).Return([]string{deletedID}, nil).Once()
+		diffsMock.On("Diff", mock.Anything, domain.PortDiff{ID: newID, Action: domain.DiffCreated}).Return(nil).Once()
+		diffsMock.On("Diff", mock.Anything, domain.PortDiff{
+			ID:      changedID,
+			Action:  domain.DiffUpdated,
+			Changes: map[string]domain.FieldChange{"timezone": {Old: "Asia/Muscat", New: "Asia/Dubai"}},
+		}).Return(nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{DryRun: true, Diffs: diffsMock})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 4, Skipped: 1, Created: 1, Updated: 1, Unchanged: 1}, *summary)
+		repoMock.AssertNotCalled(t, "SaveBulk", mock.Anything, mock.Anything)
+		diffsMock.AssertExpectations(t)
+	})
+
+	t.Run("sync should delete the ports missing from the file", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		keptID, invalidID := "AEAJM", "AEXXX"
+		kept := domain.Port{ID: &keptID, Name: "Ajman", Unlocs: []string{"AEAJM"}}
+		invalid := domain.Port{ID: &invalidID, Unlocs: []string{"XX"}}
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- kept
+		portCh <- invalid
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{kept}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("MissingIDs", mock.Anything, mock.MatchedBy(func(ids []string) bool {
+			return assert.ElementsMatch(t, []string{keptID, invalidID}, ids)
+		})).Return([]string{"AEDXB"}, nil).Once()
+		repoMock.On("Count", mock.Anything).Return(int64(10), nil).Once()
+		repoMock.On("DeleteMany", mock.Anything, []string{"AEDXB"}).Return(int64(1), nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{
+			Mode:             domain.ImportSync,
+			OnError:          domain.ImportSkip,
+			MaxErrors:        domain.NoErrorLimit,
+			MaxDeletePercent: 10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 1, Rejected: 1, Deleted: 1}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("sync should not delete anything when a rejected record had no port id", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		keptID := "AEAJM"
+		kept := domain.Port{ID: &keptID, Unlocs: []string{"AEAJM"}}
+
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error, 1)
+		portCh <- kept
+		close(portCh)
+		errCh <- &domain.RecordError{Key: "line 2", Unkeyed: true, Offset: 60, Err: domain.ErrInvalidJson}
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{kept}).Return(domain.SaveResult{}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{
+			Mode:             domain.ImportSync,
+			OnError:          domain.ImportSkip,
+			MaxErrors:        domain.NoErrorLimit,
+			MaxDeletePercent: 100,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 1, Rejected: 1}, *summary)
+		repoMock.AssertNotCalled(t, "MissingIDs", mock.Anything, mock.Anything)
+		repoMock.AssertNotCalled(t, "DeleteMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("sync should not delete more than the limit", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		keptID := "AEAJM"
+		kept := domain.Port{ID: &keptID, Unlocs: []string{"AEAJM"}}
+
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error)
+		portCh <- kept
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{kept}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("MissingIDs", mock.Anything, []string{keptID}).Return([]string{"AEAUH", "AEDXB"}, nil).Once()
+		repoMock.On("Count", mock.Anything).Return(int64(3), nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{Mode: domain.ImportSync, MaxDeletePercent: 50})
+		assert.ErrorIs(t, err, domain.ErrSyncLimitExceeded)
+		repoMock.AssertNotCalled(t, "DeleteMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("sync should not delete anything when the import fails", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		portCh := make(chan domain.Port)
+		errCh := make(chan error, 1)
+		errCh <- domain.ErrInvalidJson
+		close(errCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+
+		service := NewService(repoMock, parserMock)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{Mode: domain.ImportSync, MaxDeletePercent: 100})
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+		repoMock.AssertNotCalled(t, "MissingIDs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("resume should skip the stored ports and delete the checkpoint at the end", func(t *testing.T) {
+		ctx := domain.WithChangeSource(context.Background(), domain.ChangeSource{Kind: domain.ChangeSourceImport, Actor: "ports.json"})
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		storedID, lastID, nextID := "AEAJM", "AEAUH", "AEDXB"
+		stored := domain.Port{ID: &storedID, Unlocs: []string{"AEAJM"}, Offset: 1}
+		last := domain.Port{ID: &lastID, Unlocs: []string{"AEAUH"}, Offset: 120}
+		next := domain.Port{ID: &nextID, Unlocs: []string{"AEDXB"}, Offset: 240}
+
+		portCh := make(chan domain.Port, 3)
+		errCh := make(chan error)
+		portCh <- stored
+		portCh <- last
+		portCh <- next
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("FindCheckpoint", mock.Anything, "sha256:abc").Return(&domain.Checkpoint{
+			Fingerprint: "sha256:abc", LastKey: lastID, Offset: 120, Imported: 2,
+		}, nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{next}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("SaveCheckpoint", mock.Anything, domain.Checkpoint{
+			Fingerprint: "sha256:abc", Source: "ports.json", LastKey: nextID, Offset: 240, Imported: 3,
+		}).Return(nil).Once()
+		repoMock.On("DeleteCheckpoint", mock.Anything, "sha256:abc").Return(nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{Fingerprint: "sha256:abc", Resume: true})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 3, Imported: 1, Skipped: 2}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("resume should fail when the checkpoint record is not in the file", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		otherID := "AEDXB"
+		other := domain.Port{ID: &otherID, Unlocs: []string{"AEDXB"}, Offset: 120}
+
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error)
+		portCh <- other
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("FindCheckpoint", mock.Anything, "sha256:abc").Return(&domain.Checkpoint{
+			Fingerprint: "sha256:abc", LastKey: "AEAUH", Offset: 120,
+		}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		_, err := service.ImportPorts(ctx, domain.ImportOptions{Fingerprint: "sha256:abc", Resume: true})
+		assert.ErrorIs(t, err, domain.ErrCheckpointMismatch)
+		repoMock.AssertNotCalled(t, "SaveBulk", mock.Anything, mock.Anything)
+		repoMock.AssertNotCalled(t, "DeleteCheckpoint", mock.Anything, mock.Anything)
+	})
+
+	t.Run("resume without checkpoint should import the whole file", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		id := "AEAJM"
+		port := domain.Port{ID: &id, Unlocs: []string{"AEAJM"}}
+
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error)
+		portCh <- port
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("FindCheckpoint", mock.Anything, "sha256:abc").Return(nil, domain.ErrCheckpointNotFound).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{port}).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("SaveCheckpoint", mock.Anything, mock.MatchedBy(func(checkpoint domain.Checkpoint) bool {
+			return checkpoint.LastKey == id && checkpoint.Imported == 1
+		})).Return(nil).Once()
+		repoMock.On("DeleteCheckpoint", mock.Anything, "sha256:abc").Return(nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{Fingerprint: "sha256:abc", Resume: true})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 1, Imported: 1}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("import should save full batches with several writers", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		ids := []string{"AEAJM", "AEAUH", "AEDXB", "AEFJR", "AEKLF"}
+		ports := make([]domain.Port, len(ids))
+		portCh := make(chan domain.Port, len(ids))
+		errCh := make(chan error)
+		for i := range ids {
+			ports[i] = domain.Port{ID: &ids[i], Unlocs: []string{ids[i]}}
+			portCh <- ports[i]
+		}
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, ports[0:2]).Return(domain.SaveResult{Created: 2}, nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, ports[2:4]).Return(domain.SaveResult{Updated: 1, Unchanged: 1}, nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, ports[4:]).Return(domain.SaveResult{Unchanged: 1}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{BatchSize: 2, Writers: 3})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 5, Imported: 5, Created: 2, Updated: 1, Unchanged: 2}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("import should save a batch that waited the max latency", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		firstID, secondID := "AEAJM", "AEAUH"
+		first := domain.Port{ID: &firstID, Unlocs: []string{"AEAJM"}}
+		second := domain.Port{ID: &secondID, Unlocs: []string{"AEAUH"}}
+
+		portCh := make(chan domain.Port)
+		errCh := make(chan error)
+		saved := make(chan struct{})
+		go func() {
+			portCh <- first
+			// the parser is slow, the first port must not wait for the second one
+			<-saved
+			portCh <- second
+			close(portCh)
+		}()
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{first}).Run(func(mock.Arguments) { close(saved) }).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{second}).Return(domain.SaveResult{}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{MaxBatchLatency: 10 * time.Millisecond})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 2}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("a slow progress report should not stall the reader", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+		progressMock := &mocks.ProgressPort{}
+
+		ids := []string{"AEAJM", "AEAUH", "AEDXB"}
+		ports := make([]domain.Port, len(ids))
+		for i := range ids {
+			ports[i] = domain.Port{ID: &ids[i], Unlocs: []string{ids[i]}}
+		}
+
+		portCh := make(chan domain.Port)
+		errCh := make(chan error)
+		reported, resume := make(chan struct{}), make(chan struct{})
+		var first sync.Once
+		go func() {
+			portCh <- ports[0]
+			<-reported
+			// the first report is still being made, the reader takes the next ports meanwhile
+			for _, port := range ports[1:] {
+				select {
+				case portCh <- port:
+				case <-time.After(time.Second):
+					t.Error("reader stalled by the progress report")
+				}
+			}
+			close(resume)
+			close(portCh)
+		}()
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, mock.Anything).Return(domain.SaveResult{}, nil)
+		progressMock.On("Progress", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			first.Do(func() {
+				close(reported)
+				<-resume
+			})
+		}).Return()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{BatchSize: 1, Writers: 1, Progress: progressMock})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 3, Imported: 3}, *summary)
+	})
+
+	t.Run("import should return the error of the first failed batch of the file", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		firstID, secondID := "AEAJM", "AEAUH"
+		first := domain.Port{ID: &firstID, Unlocs: []string{"AEAJM"}}
+		second := domain.Port{ID: &secondID, Unlocs: []string{"AEAUH"}}
+		firstErr, secondErr := errors.New("first batch refused"), errors.New("second batch refused")
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- first
+		portCh <- second
+		close(portCh)
+
+		secondFailed := make(chan time.Time)
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{first}).WaitUntil(secondFailed).Return(domain.SaveResult{}, firstErr).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{second}).Run(func(mock.Arguments) { close(secondFailed) }).Return(domain.SaveResult{}, secondErr).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{BatchSize: 1, Writers: 2})
+		assert.ErrorIs(t, err, firstErr)
+		assert.Equal(t, int64(0), summary.Imported)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("checkpoint should only cover the batches stored without a gap", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		firstID, secondID := "AEAJM", "AEAUH"
+		first := domain.Port{ID: &firstID, Unlocs: []string{"AEAJM"}, Offset: 1}
+		second := domain.Port{ID: &secondID, Unlocs: []string{"AEAUH"}, Offset: 120}
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- first
+		portCh <- second
+		close(portCh)
+
+		secondSaved := make(chan time.Time)
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{first}).WaitUntil(secondSaved).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{second}).Run(func(mock.Arguments) { close(secondSaved) }).Return(domain.SaveResult{}, nil).Once()
+		repoMock.On("SaveCheckpoint", mock.Anything, mock.MatchedBy(func(checkpoint domain.Checkpoint) bool {
+			return checkpoint.LastKey == secondID && checkpoint.Offset == 120 && checkpoint.Imported == 2
+		})).Return(nil).Once()
+		repoMock.On("DeleteCheckpoint", mock.Anything, "sha256:abc").Return(nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{Fingerprint: "sha256:abc", BatchSize: 1, Writers: 2})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 2}, *summary)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("import with the copy loader should copy the batches", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		id := "AEAJM"
+		port := domain.Port{ID: &id, Unlocs: []string{"AEAJM"}}
+
+		portCh := make(chan domain.Port, 1)
+		errCh := make(chan error)
+		portCh <- port
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("CopyBulk", mock.Anything, []domain.Port{port}).Return(domain.SaveResult{}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{Loader: domain.LoadCopy})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 1, Imported: 1}, *summary)
+		repoMock.AssertExpectations(t)
+		repoMock.AssertNotCalled(t, "SaveBulk", mock.Anything, mock.Anything)
+	})
+
+	t.Run("atomic import should save the file in a transaction", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		firstID, secondID := "AEAJM", "AEAUH"
+		first := domain.Port{ID: &firstID, Unlocs: []string{"AEAJM"}}
+		second := domain.Port{ID: &secondID, Unlocs: []string{"AEAUH"}}
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- first
+		portCh <- second
+		close(portCh)
+
+		inTransaction := false
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("InTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+			inTransaction = true
+			defer func() { inTransaction = false }()
+			return fn(ctx)
+		}).Once()
+		repoMock.On("SaveBulk", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			assert.True(t, inTransaction)
+		}).Return(domain.SaveResult{}, nil).Twice()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{Atomic: true, BatchSize: 1, Fingerprint: "sha256:abc"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportSummary{Parsed: 2, Imported: 2}, *summary)
+		repoMock.AssertExpectations(t)
+		repoMock.AssertNotCalled(t, "SaveCheckpoint", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed atomic import should count no imported port", func(t *testing.T) {
+		ctx := context.Background()
+		repoMock := &mocks.RepositoryPort{}
+		parserMock := &mocks.ParserPort{}
+
+		validID := "AEAJM"
+		valid := domain.Port{ID: &validID, Unlocs: []string{"AEAJM"}}
+
+		portCh := make(chan domain.Port, 2)
+		errCh := make(chan error)
+		portCh <- valid
+		portCh <- domain.Port{Unlocs: []string{"XX"}}
+		close(portCh)
+
+		parserMock.On("Parse", mock.Anything).Return((<-chan domain.Port)(portCh), (<-chan error)(errCh))
+		repoMock.On("InTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).Once()
+		repoMock.On("SaveBulk", mock.Anything, []domain.Port{valid}).Return(domain.SaveResult{Created: 1}, nil).Once()
+
+		service := NewService(repoMock, parserMock)
+		summary, err := service.ImportPorts(ctx, domain.ImportOptions{Atomic: true, BatchSize: 1})
+		assert.ErrorIs(t, err, domain.ErrInvalidPort)
+		assert.Equal(t, int64(0), summary.Imported)
+		assert.Zero(t, summary.Created)
+		assert.Zero(t, summary.Updated)
+		assert.Zero(t, summary.Unchanged)
+		repoMock.AssertExpectations(t)
+	})
+}
+
+func TestBackfillCountryCodes(t *testing.T) {
+	t.Run("backfill should save the ports whose codes changed, page by page", func(t *testing.T) {
+		repoMock := new(mocks.RepositoryPort)
+		portsService := NewService(repoMock, nil)
+		ctx := context.Background()
+
+		staleID, currentID, changedID := "AEAJM", "AEDXB", "CNCGU"
+		stale := domain.Port{ID: &staleID, Country: "United Arab Emirates", Province: "Ajman", Version: 3}
+		current := domain.Port{ID: &currentID, Country: "United Arab Emirates", CountryCode: "AE", Version: 1}
+		changed := domain.Port{ID: &changedID, Country: "China", Version: 2}
+
+		repoMock.On("List", ctx, domain.ListQuery{Limit: backfillPageSize}).
+			Return(&domain.PortPage{Ports: []domain.Port{stale, current}, NextCursor: "next"}, nil).Once()
+		repoMock.On("List", ctx, domain.ListQuery{Limit: backfillPageSize, Cursor: "next"}).
+			Return(&domain.PortPage{Ports: []domain.Port{changed}}, nil).Once()
+
+		resolved := stale
+		resolved.CountryCode, resolved.SubdivisionCode = "AE", "AE-AJ"
+		repoMock.On("SaveIfVersion", ctx, resolved, int64(3)).Return(int64(4), nil).Once()
+		// written meanwhile, the write resolved the codes
+		repoMock.On("SaveIfVersion", ctx, mock.MatchedBy(func(port domain.Port) bool {
+			return *port.ID == changedID && port.CountryCode == "CN"
+		}), int64(2)).Return(int64(0), domain.ErrVersionConflict).Once()
+
+		saved, err := portsService.BackfillCountryCodes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), saved)
+		repoMock.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/guil95/ports-service/internal/core/domain/iso3166"
+)
+
+// withdrawnCountryCodes maps the ISO 3166-1 codes withdrawn from the standard but still prefixing UN/LOCODEs
+// to the countries that took over their territory.
+var withdrawnCountryCodes = map[string][]string{
+	// Netherlands Antilles, dissolved in 2010; Bonaire, Sint Eustatius and Saba became Dutch municipalities
+	"AN": {"BQ", "CW", "SX", "NL"},
+}
+
+// ResolveCountry sets CountryCode and SubdivisionCode from the Country and Province display names.
+// Names missing from ISO 3166 (e.g. "Netherlands Antilles", dissolved in 2010) leave the codes empty.
+func (p *Port) ResolveCountry() {
+	p.CountryCode, p.SubdivisionCode = "", ""
+
+	country, ok := iso3166.LookupCountry(p.Country)
+	if !ok {
+		return
+	}
+	p.CountryCode = country.Alpha2
+
+	if subdivision, ok := iso3166.LookupSubdivision(country.Alpha2, p.Province); ok {
+		p.SubdivisionCode = subdivision.Code
+	}
+}
+
+// unlocBelongsTo reports whether the UN/LOCODE is one of the country with the ISO 3166-1 alpha-2 code.
+func unlocBelongsTo(unloc, countryCode string) bool {
+	prefix, countryCode := strings.ToUpper(unloc[:2]), strings.ToUpper(countryCode)
+	if prefix == countryCode {
+		return true
+	}
+
+	return slices.Contains(withdrawnCountryCodes[prefix], countryCode)
+}
@@ -0,0 +1,35 @@
+//go:build unit
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveCountry(t *testing.T) {
+	t.Run("country and province names should resolve to ISO 3166 codes", func(t *testing.T) {
+		port := Port{Country: "United Arab Emirates", Province: "Abu Z¸aby [Abu Dhabi]"}
+		port.ResolveCountry()
+
+		assert.Equal(t, "AE", port.CountryCode)
+		assert.Equal(t, "AE-AZ", port.SubdivisionCode)
+	})
+
+	t.Run("unknown province should only resolve the country", func(t *testing.T) {
+		port := Port{Country: "China", Province: "Shenzhen", SubdivisionCode: "CN-GD"}
+		port.ResolveCountry()
+
+		assert.Equal(t, "CN", port.CountryCode)
+		assert.Empty(t, port.SubdivisionCode)
+	})
+
+	t.Run("former country should leave the codes empty", func(t *testing.T) {
+		port := Port{Country: "Netherlands Antilles", Province: "Curaçao", CountryCode: "NL"}
+		port.ResolveCountry()
+
+		assert.Empty(t, port.CountryCode)
+		assert.Empty(t, port.SubdivisionCode)
+	})
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "slices"
+
+// DiffAction is what an import does to a port.
+type DiffAction string
+
+const (
+	DiffCreated   DiffAction = "created"
+	DiffUpdated   DiffAction = "updated"
+	DiffUnchanged DiffAction = "unchanged"
+	DiffDeleted   DiffAction = "deleted"
+)
+
+// PortDiff is the change an import makes to a stored port, keyed by field like the port history.
+// Changes is empty unless the port is updated.
+type PortDiff struct {
+	ID      string                 `json:"id"`
+	Action  DiffAction             `json:"action"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// Diff returns the fields that differ between the stored port and next. Only the fields an import
+// writes are compared, a nil list equals an empty one.
+func (p Port) Diff(next Port) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	text := func(field, old, new string) {
+		if old != new {
+			changes[field] = FieldChange{Old: old, New: new}
+		}
+	}
+	list := func(field string, old, new []string) {
+		if !slices.Equal(old, new) {
+			changes[field] = FieldChange{Old: old, New: new}
+		}
+	}
+
+	text("name", p.Name, next.Name)
+	text("city", p.City, next.City)
+	text("country", p.Country, next.Country)
+	text("country_code", p.CountryCode, next.CountryCode)
+	list("alias", p.Alias, next.Alias)
+	list("regions", p.Regions, next.Regions)
+	if !slices.Equal(p.Coordinates, next.Coordinates) {
+		changes["coordinates"] = FieldChange{Old: p.Coordinates, New: next.Coordinates}
+	}
+	text("province", p.Province, next.Province)
+	text("subdivision_code", p.SubdivisionCode, next.SubdivisionCode)
+	text("timezone", p.Timezone, next.Timezone)
+	list("unlocs", p.Unlocs, next.Unlocs)
+	text("code", p.Code, next.Code)
+
+	return changes
+}
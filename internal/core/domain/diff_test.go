@@ -0,0 +1,33 @@
+//go:build unit
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("same port should have no changes", func(t *testing.T) {
+		stored := validPort()
+		next := validPort()
+		stored.Alias, next.Alias = nil, []string{}
+
+		assert.Empty(t, stored.Diff(next))
+	})
+
+	t.Run("changed fields should be listed", func(t *testing.T) {
+		stored := validPort()
+		next := validPort()
+		next.Name = "Ajman Port"
+		next.Coordinates = []float64{55.52, 25.41}
+		next.Unlocs = []string{"AEAJM", "AEAJN"}
+
+		assert.Equal(t, map[string]FieldChange{
+			"name":        {Old: "Ajman", New: "Ajman Port"},
+			"coordinates": {Old: []float64{55.5136433, 25.4052165}, New: []float64{55.52, 25.41}},
+			"unlocs":      {Old: []string{"AEAJM"}, New: []string{"AEAJM", "AEAJN"}},
+		}, stored.Diff(next))
+	})
+}
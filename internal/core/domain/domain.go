@@ -1,15 +1,137 @@
 package domain
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
 type Port struct {
-	ID          *string   `json:"id,omitempty" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	City        string    `json:"city" db:"city"`
-	Country     string    `json:"country" db:"country"`
-	Alias       []string  `json:"alias" db:"alias"`
-	Regions     []string  `json:"regions" db:"regions"`
-	Coordinates []float64 `json:"coordinates" db:"coordinates"`
-	Province    string    `json:"province" db:"province"`
-	Timezone    string    `json:"timezone" db:"timezone"`
-	Unlocs      []string  `json:"unlocs" db:"unlocs"`
-	Code        string    `json:"code" db:"code"`
+	ID              *string   `json:"id,omitempty" db:"id"`
+	Name            string    `json:"name" db:"name"`
+	City            string    `json:"city" db:"city"`
+	Country         string    `json:"country" db:"country"`
+	CountryCode     string    `json:"country_code" db:"country_code"` // ISO 3166-1 alpha-2, see ResolveCountry
+	Alias           []string  `json:"alias" db:"alias"`
+	Regions         []string  `json:"regions" db:"regions"`
+	Coordinates     []float64 `json:"coordinates" db:"coordinates"`
+	Province        string    `json:"province" db:"province"`
+	SubdivisionCode string    `json:"subdivision_code" db:"subdivision_code"` // ISO 3166-2, see ResolveCountry
+	Timezone        string    `json:"timezone" db:"timezone"`
+	Unlocs          []string  `json:"unlocs" db:"unlocs"`
+	Code            string    `json:"code" db:"code"`
+	Version         int64     `json:"-" db:"version"` // bumped on every change of the stored port, exposed as ETag
+	Offset          int64     `json:"-" db:"-"`       // byte offset of the record in an imported file, set by the parsers
+}
+
+// ContentHash is the hex SHA-256 of the stored fields of the port, in a canonical form: a nil and an
+// empty list hash the same. The repository skips the writes that would not change it.
+func (p Port) ContentHash() string {
+	orEmpty := func(values []string) []string {
+		if values == nil {
+			return []string{}
+		}
+		return values
+	}
+	coordinates := p.Coordinates
+	if coordinates == nil {
+		coordinates = []float64{}
+	}
+	var id string
+	if p.ID != nil {
+		id = *p.ID
+	}
+
+	// an array keeps the order of the fields, whatever the JSON tags of Port
+	content, _ := json.Marshal([]interface{}{
+		id, p.Name, p.City, p.Country, p.CountryCode, orEmpty(p.Alias), orEmpty(p.Regions), coordinates,
+		p.Province, p.SubdivisionCode, p.Timezone, orEmpty(p.Unlocs), p.Code,
+	})
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// AnyVersion is the version of a write that only requires the port to exist (If-Match: *).
+const AnyVersion int64 = -1
+
+// Patch changes some fields of a Port, e.g. a JSON Merge Patch or a JSON Patch document.
+type Patch interface {
+	Apply(port Port) (Port, error)
+}
+
+// NearbyQuery searches ports within RadiusKm of a point, closest first.
+type NearbyQuery struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	Limit     int
+}
+
+// NearbyPort is a Port with its great-circle distance to the searched point.
+type NearbyPort struct {
+	Port
+	DistanceKm float64 `json:"distance_km" db:"distance_km"`
+}
+
+// ListQuery filters and paginates the port listing. Filters are optional and combined with AND.
+// Sort is one of the sortable fields ("id", "name", "city", "country", "code"), prefixed with "-"
+// for descending order. Cursor is the NextCursor of the previous page.
+// CountryCode is an ISO 3166-1 alpha-2 or alpha-3 code.
+type ListQuery struct {
+	Country     string
+	CountryCode string
+	Province    string
+	City        string
+	Region      string
+	Timezone    string
+	Code        string
+	Sort        string
+	Cursor      string
+	Limit       int
+}
+
+// PortPage is a page of the port listing. NextCursor is empty on the last page.
+type PortPage struct {
+	Ports      []Port `json:"ports"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SearchQuery is a free-text search over the port name, city, province and aliases.
+type SearchQuery struct {
+	Term  string
+	Limit int
+}
+
+// SearchResult is a Port matched by a SearchQuery, with its relevance between 0 and 1.
+type SearchResult struct {
+	Port
+	Score float64 `json:"score" db:"score"`
+}
+
+// Country is an ISO 3166-1 country with the number of ports stored for it.
+type Country struct {
+	Code   string `json:"code"`
+	Alpha3 string `json:"alpha3"`
+	Name   string `json:"name"`
+	Ports  int64  `json:"ports"`
+}
+
+// PortChange is an entry of the port history: one write with the version it replaced.
+type PortChange struct {
+	ID        int64                  `json:"id" db:"id"`
+	PortID    string                 `json:"port_id" db:"port_id"`
+	Operation string                 `json:"operation" db:"operation"`
+	ChangedAt time.Time              `json:"changed_at" db:"changed_at"`
+	Source    string                 `json:"source" db:"source"`
+	Actor     string                 `json:"actor,omitempty" db:"actor"`
+	RunID     string                 `json:"run_id,omitempty" db:"run_id"`
+	Previous  *Port                  `json:"previous,omitempty" db:"previous"`
+	Diff      map[string]FieldChange `json:"diff" db:"diff"`
+}
+
+// FieldChange is the value of a field before and after a write.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
 }
@@ -0,0 +1,36 @@
+//go:build unit
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	t.Run("same port should have the same hash", func(t *testing.T) {
+		stored := validPort()
+		next := validPort()
+		stored.Alias, next.Alias = nil, []string{}
+		next.Version, next.Offset = 3, 1024
+
+		assert.Len(t, stored.ContentHash(), 64)
+		assert.Equal(t, stored.ContentHash(), next.ContentHash())
+	})
+
+	t.Run("changed fields should change the hash", func(t *testing.T) {
+		stored := validPort()
+		for _, change := range []func(*Port){
+			func(p *Port) { p.Name = "Ajman Port" },
+			func(p *Port) { p.Coordinates = []float64{55.52, 25.41} },
+			func(p *Port) { p.Unlocs = []string{"AEAJM", "AEAJN"} },
+			// the fields are not mixed up when their values move
+			func(p *Port) { p.Province, p.Timezone = p.Timezone, p.Province },
+		} {
+			next := validPort()
+			change(&next)
+			assert.NotEqual(t, stored.ContentHash(), next.ContentHash())
+		}
+	})
+}
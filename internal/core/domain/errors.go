@@ -3,5 +3,18 @@ package domain
 import "errors"
 
 var ErrPortNotFound = errors.New("port not found")
+var ErrPortDeleted = errors.New("port is deleted, restore it first")
 var ErrInvalidPort = errors.New("invalid port")
 var ErrInvalidJson = errors.New("invalid json")
+var ErrInvalidCsv = errors.New("invalid csv")
+var ErrInvalidQuery = errors.New("invalid query")
+var ErrInvalidPatch = errors.New("invalid patch")
+var ErrVersionConflict = errors.New("port was changed by someone else")
+var ErrCountryNotFound = errors.New("country not found")
+var ErrErrorBudgetExceeded = errors.New("too many rejected records")
+var ErrSyncLimitExceeded = errors.New("sync would delete too many ports")
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+var ErrCheckpointMismatch = errors.New("checkpoint does not match the file")
+var ErrImportJobNotFound = errors.New("import job not found")
+var ErrImportJobFinished = errors.New("import job already finished")
+var ErrImportRunNotFound = errors.New("import run not found")
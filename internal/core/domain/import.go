@@ -0,0 +1,146 @@
+package domain
+
+import "time"
+
+// ImportErrorMode tells an import what to do with a record it cannot import.
+type ImportErrorMode int
+
+const (
+	// ImportAbort stops the import at the first bad record.
+	ImportAbort ImportErrorMode = iota
+	// ImportSkip rejects the bad records and goes on, until the error budget is spent.
+	ImportSkip
+)
+
+// ImportMode tells an import what the file holds.
+type ImportMode int
+
+const (
+	// ImportUpsert creates and updates the ports of the file, the other ports are left alone.
+	ImportUpsert ImportMode = iota
+	// ImportSync treats the file as the complete dataset, the ports missing from it are deleted.
+	ImportSync
+)
+
+func (m ImportMode) String() string {
+	if m == ImportSync {
+		return "sync"
+	}
+	return "upsert"
+}
+
+// ImportLoader is how an import writes its batches to the database.
+type ImportLoader int
+
+const (
+	// LoadInsert saves a batch with a multi-row INSERT, see RepositoryPort.SaveBulk.
+	LoadInsert ImportLoader = iota
+	// LoadCopy streams a batch with COPY and merges it in one statement, see RepositoryPort.CopyBulk.
+	// It is faster for large batches and has no limit on their size.
+	LoadCopy
+)
+
+// NoErrorLimit lets an ImportSkip import reject any number of records.
+const NoErrorLimit = -1
+
+// DefaultMaxDeletePercent is the ImportOptions.MaxDeletePercent the CLI and HTTP imports use unless told otherwise.
+const DefaultMaxDeletePercent = 10
+
+// Defaults of the ImportOptions batching, used when they are zero.
+const (
+	DefaultImportBatchSize    = 200
+	DefaultImportWriters      = 4
+	DefaultImportBatchLatency = time.Second
+)
+
+// ImportOptions configures ServicePort.ImportPorts, the zero value aborts at the first bad record.
+type ImportOptions struct {
+	Mode    ImportMode
+	OnError ImportErrorMode
+	Loader  ImportLoader
+	// Rejects receives the records an ImportSkip import rejected, it may be nil.
+	Rejects RejectPort
+	// MaxErrors is the number of records an ImportSkip import may reject before it fails, or NoErrorLimit.
+	MaxErrors int
+	// DryRun compares the ports with the stored ones instead of saving them, and counts them by DiffAction.
+	DryRun bool
+	// Diffs receives the ports a DryRun import would create, update or delete, it may be nil.
+	Diffs DiffPort
+	// Progress receives the summary after every stored batch, it may be nil.
+	Progress ProgressPort
+	// MaxDeletePercent blocks the deletions of an ImportSync import when they would remove more than
+	// this share of the stored ports, between 0 and 100.
+	MaxDeletePercent float64
+	// Fingerprint identifies the imported file, a Checkpoint is saved after every stored batch when it is set.
+	Fingerprint string
+	// Resume skips the ports stored by a previous import of the same file, up to its Checkpoint.
+	Resume bool
+	// Digest measures a source read without a checksum, ServicePort.RunImport records its Size and Checksum
+	// once the import succeeded. It may be nil.
+	Digest DigestPort
+	// SkipIfUnchanged makes ServicePort.RunImport skip the file when the last successful import, in
+	// the same mode, read a file with the same checksum.
+	SkipIfUnchanged bool
+	// BatchSize is the number of ports saved together.
+	BatchSize int
+	// Writers is the number of batches saved concurrently while the file is read.
+	Writers int
+	// Atomic saves the whole file in a single transaction, the ports change only when the import succeeds.
+	// The batches are saved by a single writer and no Checkpoint is saved.
+	Atomic bool
+	// MaxBatchLatency is how long a port may wait for its batch to fill up before the batch is saved anyway.
+	MaxBatchLatency time.Duration
+}
+
+// ImportSummary counts the records of an import. Parsed counts the records read from the file, the bad
+// ones included, and Imported the ports saved. Created, Updated and Unchanged split the saved ports, or the
+// ones a dry run compared. Deleted is counted by ImportSync imports. Skipped counts the ports stored before
+// a checkpoint, by resumed imports, and the deleted ports, which an import leaves deleted.
+type ImportSummary struct {
+	Parsed    int64 `json:"parsed,omitempty"`
+	Imported  int64 `json:"imported"`
+	Rejected  int64 `json:"rejected"`
+	Skipped   int64 `json:"skipped,omitempty"`
+	Created   int64 `json:"created"`
+	Updated   int64 `json:"updated"`
+	Unchanged int64 `json:"unchanged"`
+	Deleted   int64 `json:"deleted"`
+}
+
+// SaveResult counts the ports of a bulk save: the ports whose content did not change are left unchanged,
+// not written, and the deleted ports are skipped, they stay deleted until restored.
+type SaveResult struct {
+	Created   int64 `db:"created"`
+	Updated   int64 `db:"updated"`
+	Unchanged int64 `db:"unchanged"`
+	Skipped   int64 `db:"skipped"`
+}
+
+// RecordError is a record of an imported file that could not be imported: it could not be decoded,
+// it is not a valid port or the database refused it. Key is the port ID, or the position of the
+// record when it has none (e.g. "line 12") and Unkeyed is set, and Offset its byte offset in the file.
+type RecordError struct {
+	Key     string
+	Unkeyed bool
+	Offset  int64
+	Err     error
+}
+
+func (e *RecordError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Checkpoint is the progress of an import, saved after every stored batch. LastKey and Offset locate
+// the last stored record of the file identified by Fingerprint, Imported counts the ports stored so far.
+type Checkpoint struct {
+	Fingerprint string    `db:"fingerprint"`
+	Source      string    `db:"source"`
+	LastKey     string    `db:"last_key"`
+	Offset      int64     `db:"byte_offset"`
+	Imported    int64     `db:"imported"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// ImportJobStatus is the state of an ImportJob.
+type ImportJobStatus string
+
+const (
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobSucceeded ImportJobStatus = "succeeded"
+	ImportJobFailed    ImportJobStatus = "failed"
+	ImportJobCanceled  ImportJobStatus = "canceled"
+	// ImportJobUnchanged is a job that imported nothing, the file was the last one imported.
+	ImportJobUnchanged ImportJobStatus = "unchanged"
+)
+
+// ImportJob is an import running in the background of the HTTP server. Summary counts the ports
+// imported so far while it runs, Error tells why a failed or canceled job stopped.
+type ImportJob struct {
+	ID              string          `json:"id"`
+	Status          ImportJobStatus `json:"status"`
+	Format          string          `json:"format"`
+	Source          string          `json:"source"`
+	Summary         ImportSummary   `json:"summary"`
+	Error           string          `json:"error,omitempty"`
+	CancelRequested bool            `json:"cancel_requested"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Finished tells whether the job stopped, whatever the outcome.
+func (j ImportJob) Finished() bool {
+	return j.Status != ImportJobRunning
+}
+
+// ImportJobRequest describes the import of an ImportJob: Parser reads the uploaded file, named by Source,
+// of Size bytes and Checksum "sha256:<hex>".
+type ImportJobRequest struct {
+	Format   string
+	Source   string
+	Size     int64
+	Checksum string
+	Options  ImportOptions
+	Parser   ParserPort
+	// Release frees the file of Parser once the job is finished, it may be nil.
+	Release func()
+}
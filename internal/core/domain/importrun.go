@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// ImportOutcome is the result of an ImportRun.
+type ImportOutcome string
+
+const (
+	ImportRunning   ImportOutcome = "running"
+	ImportSucceeded ImportOutcome = "succeeded"
+	ImportFailed    ImportOutcome = "failed"
+	ImportCanceled  ImportOutcome = "canceled"
+	// ImportUnchanged is a run skipped because the last successful import read the same file.
+	ImportUnchanged ImportOutcome = "unchanged"
+)
+
+// MaxImportSourceLength is the longest Source, in characters, of an import run or job.
+const MaxImportSourceLength = 255
+
+// ImportRun records a run of an import, from the command line or an ImportJob. Size and Checksum
+// ("sha256:<hex>") are the ones of the file as read, compressed or not; for the standard input they are
+// only known once a successful import read it. Summary counts the records while it runs and once it is over.
+type ImportRun struct {
+	ID         string        `json:"id"`
+	Source     string        `json:"source"`
+	Format     string        `json:"format"`
+	Mode       string        `json:"mode"`
+	DryRun     bool          `json:"dry_run"`
+	Size       int64         `json:"size,omitempty"`
+	Checksum   string        `json:"checksum,omitempty"`
+	Outcome    ImportOutcome `json:"outcome"`
+	Error      string        `json:"error,omitempty"`
+	Summary    ImportSummary `json:"summary"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
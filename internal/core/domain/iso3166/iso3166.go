@@ -0,0 +1,226 @@
+// Package iso3166 is an embedded copy of the ISO 3166-1 countries and ISO 3166-2 subdivisions.
+//
+// iso_3166-1.json and iso_3166-2.json are trimmed from the iso-codes project (version 4.15.0,
+// https://salsa.debian.org/iso-codes-team/iso-codes), refresh them from a newer release when
+// countries or subdivisions change.
+package iso3166
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Country is an ISO 3166-1 country.
+type Country struct {
+	Alpha2       string `json:"alpha_2"`
+	Alpha3       string `json:"alpha_3"`
+	Name         string `json:"name"`
+	OfficialName string `json:"official_name,omitempty"`
+	CommonName   string `json:"common_name,omitempty"`
+}
+
+// Subdivision is an ISO 3166-2 subdivision, its code is prefixed with the country alpha-2 code (e.g. "AE-AZ").
+type Subdivision struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Parent string `json:"parent,omitempty"`
+}
+
+//go:embed iso_3166-1.json
+var countriesJSON []byte
+
+//go:embed iso_3166-2.json
+var subdivisionsJSON []byte
+
+// formerNames are country names still found in port data but replaced in ISO 3166-1.
+var formerNames = map[string]string{
+	"burma":      "MM",
+	"cape verde": "CV",
+	"macedonia":  "MK",
+	"swaziland":  "SZ",
+	"turkey":     "TR",
+}
+
+// genericWords are left out when matching subdivision names, data sources disagree on them
+// ("Liaoning" and "Liaoning Sheng", "Región de Antofagasta" and "Antofagasta").
+var genericWords = map[string]bool{
+	"de": true, "del": true, "division": true, "emirate": true, "governorate": true, "of": true,
+	"prefecture": true, "province": true, "provincia": true, "region": true, "sar": true,
+	"sheng": true, "shi": true, "state": true, "the": true, "zizhiqu": true,
+}
+
+var (
+	countries         []Country
+	countryByCode     = map[string]int{}
+	countryByName     = map[string]int{}
+	subdivisionByCode = map[string]Subdivision{}
+	subdivisionsBy    = map[string][2]subdivisionIndex{}
+)
+
+func init() {
+	if err := json.Unmarshal(countriesJSON, &countries); err != nil {
+		panic("iso3166: " + err.Error())
+	}
+	var subdivisions []Subdivision
+	if err := json.Unmarshal(subdivisionsJSON, &subdivisions); err != nil {
+		panic("iso3166: " + err.Error())
+	}
+
+	for i, c := range countries {
+		countryByCode[c.Alpha2] = i
+		countryByCode[c.Alpha3] = i
+		for _, name := range []string{c.Name, c.OfficialName, c.CommonName} {
+			if name != "" {
+				countryByName[normalize(name)] = i
+			}
+		}
+	}
+	for name, code := range formerNames {
+		countryByName[name] = countryByCode[code]
+	}
+
+	for _, s := range subdivisions {
+		subdivisionByCode[s.Code] = s
+		country := s.Code[:2]
+		if _, ok := subdivisionsBy[country]; !ok {
+			subdivisionsBy[country] = [2]subdivisionIndex{{}, {}}
+		}
+		for i, keyOf := range subdivisionKeys {
+			for _, variant := range nameVariants(s.Name) {
+				subdivisionsBy[country][i].add(keyOf(variant), s)
+			}
+		}
+	}
+}
+
+// subdivisionKeys are the ways a subdivision name is matched, from the strictest.
+var subdivisionKeys = [2]func(string) string{normalize, withoutGenericWords}
+
+// subdivisionIndex maps a name key to the subdivisions sharing it. A top-level subdivision wins
+// over the ones nested in it ("Dhaka" the division over "Dhaka" the district), other clashes
+// are ambiguous and never match.
+type subdivisionIndex map[string][]Subdivision
+
+func (idx subdivisionIndex) add(key string, s Subdivision) {
+	if key == "" {
+		return
+	}
+	for _, other := range idx[key] {
+		if other.Code == s.Code {
+			return
+		}
+	}
+	idx[key] = append(idx[key], s)
+}
+
+func (idx subdivisionIndex) find(key string) (Subdivision, bool) {
+	var found []Subdivision
+	for _, s := range idx[key] {
+		if s.Parent == "" {
+			found = append(found, s)
+		}
+	}
+	if len(found) == 0 {
+		found = idx[key]
+	}
+	if len(found) != 1 {
+		return Subdivision{}, false
+	}
+	return found[0], true
+}
+
+// Countries returns every country, sorted by alpha-2 code.
+func Countries() []Country {
+	return append([]Country(nil), countries...)
+}
+
+// CountryByCode finds a country by its alpha-2 or alpha-3 code, case-insensitively.
+func CountryByCode(code string) (Country, bool) {
+	i, ok := countryByCode[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Country{}, false
+	}
+	return countries[i], true
+}
+
+// LookupCountry finds a country by its short, official or common name, ignoring case,
+// accents and punctuation. A few former names ("Turkey", "Cape Verde") are also known.
+func LookupCountry(name string) (Country, bool) {
+	i, ok := countryByName[normalize(name)]
+	if !ok {
+		return Country{}, false
+	}
+	return countries[i], true
+}
+
+// SubdivisionByCode finds a subdivision by its full code, e.g. "AE-AZ", case-insensitively.
+func SubdivisionByCode(code string) (Subdivision, bool) {
+	s, ok := subdivisionByCode[strings.ToUpper(strings.TrimSpace(code))]
+	return s, ok
+}
+
+// LookupSubdivision finds a subdivision of the country by its name, ignoring case, accents,
+// punctuation and words like "Province" or "Region". Names in the "local [english]" form of
+// the port data, e.g. "Abu Z¸aby [Abu Dhabi]", are tried in full and part by part.
+func LookupSubdivision(countryCode, name string) (Subdivision, bool) {
+	indexes, ok := subdivisionsBy[strings.ToUpper(countryCode)]
+	if !ok || strings.TrimSpace(name) == "" {
+		return Subdivision{}, false
+	}
+
+	for i, keyOf := range subdivisionKeys {
+		for _, candidate := range nameVariants(name) {
+			if s, ok := indexes[i].find(keyOf(candidate)); ok {
+				return s, true
+			}
+		}
+	}
+
+	return Subdivision{}, false
+}
+
+// nameVariants splits a "local [english]" name into the full name and both of its parts.
+func nameVariants(name string) []string {
+	variants := []string{name}
+	if open := strings.Index(name, "["); open >= 0 {
+		inside, _, _ := strings.Cut(name[open+1:], "]")
+		variants = append(variants, name[:open], inside)
+	}
+	return variants
+}
+
+// spacingMarks are standalone accents that some sources use in place of combining ones ("Z¸aby").
+var spacingMarks = strings.NewReplacer("¸", "", "´", "", "`", "", "¨", "", "˜", "", "^", "", "¯", "", "˛", "", "’", "", "‘", "", "'", "")
+
+// normalize folds a name to unaccented lower-case words separated by single spaces.
+func normalize(name string) string {
+	folded, _, err := transform.String(
+		transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC),
+		spacingMarks.Replace(name),
+	)
+	if err != nil {
+		folded = name
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+func withoutGenericWords(name string) string {
+	var kept []string
+	for _, word := range strings.Fields(normalize(name)) {
+		if !genericWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
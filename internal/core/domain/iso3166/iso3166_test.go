@@ -0,0 +1,73 @@
+//go:build unit
+
+package iso3166
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountryByCode(t *testing.T) {
+	for _, code := range []string{"AE", "are", " ae "} {
+		country, ok := CountryByCode(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, "AE", country.Alpha2, code)
+	}
+
+	_, ok := CountryByCode("ZZ")
+	assert.False(t, ok)
+}
+
+func TestLookupCountry(t *testing.T) {
+	cases := map[string]string{
+		"United Arab Emirates":            "AE",
+		"Bolivia, Plurinational State of": "BO",
+		"South Korea":                     "KR",
+		"Cote d'Ivoire":                   "CI",
+		"Côte d'Ivoire":                   "CI",
+		"turkey":                          "TR",
+		"Cape Verde":                      "CV",
+	}
+	for name, code := range cases {
+		country, ok := LookupCountry(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, code, country.Alpha2, name)
+	}
+
+	_, ok := LookupCountry("Netherlands Antilles")
+	assert.False(t, ok)
+}
+
+func TestSubdivisionByCode(t *testing.T) {
+	subdivision, ok := SubdivisionByCode("ae-az")
+	assert.True(t, ok)
+	assert.Equal(t, "Abū Z̧aby", subdivision.Name)
+
+	_, ok = SubdivisionByCode("AE-ZZ")
+	assert.False(t, ok)
+}
+
+func TestLookupSubdivision(t *testing.T) {
+	cases := []struct {
+		country, name, code string
+	}{
+		{"AE", "Abu Z¸aby [Abu Dhabi]", "AE-AZ"},
+		{"AE", "Abū Z̧aby", "AE-AZ"},
+		{"CN", "Liaoning", "CN-LN"},
+		{"CL", "Región de Antofagasta", "CL-AN"},
+		{"BD", "Dhaka Division", "BD-C"},
+		{"SE", "Skåne län", "SE-M"},
+		{"br", "sao paulo", "BR-SP"},
+	}
+	for _, c := range cases {
+		subdivision, ok := LookupSubdivision(c.country, c.name)
+		assert.True(t, ok, c.name)
+		assert.Equal(t, c.code, subdivision.Code, c.name)
+	}
+
+	_, ok := LookupSubdivision("CN", "Shenzhen")
+	assert.False(t, ok)
+	_, ok = LookupSubdivision("AE", "")
+	assert.False(t, ok)
+}
@@ -2,22 +2,114 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 // ServicePort (Primary Port)
 type ServicePort interface {
 	CreateOrUpdate(ctx context.Context, port Port) error
+	UpdateIfMatch(ctx context.Context, port Port, version int64) (int64, error)
+	Patch(ctx context.Context, portID string, patch Patch, version int64) (*Port, error)
 	FindByID(ctx context.Context, portID string) (*Port, error)
-	ImportPorts(ctx context.Context) error
+	Resolve(ctx context.Context, identifier string) (*Port, error)
+	FindByCode(ctx context.Context, code string) ([]Port, error)
+	FindNearby(ctx context.Context, query NearbyQuery) ([]NearbyPort, error)
+	List(ctx context.Context, query ListQuery) (*PortPage, error)
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	History(ctx context.Context, portID string) ([]PortChange, error)
+	Countries(ctx context.Context) ([]Country, error)
+	// Delete and Restore require the stored version to be version, unless it is 0 or AnyVersion.
+	Delete(ctx context.Context, portID string, version int64) error
+	Restore(ctx context.Context, portID string, version int64) error
+	Purge(ctx context.Context, olderThan time.Duration) (int64, error)
+	// BackfillCountryCodes resolves the ISO 3166 codes of the stored ports again and saves the ones that
+	// changed, e.g. the ports stored before the codes existed. It returns how many it saved.
+	BackfillCountryCodes(ctx context.Context) (int64, error)
+	ImportPorts(ctx context.Context, options ImportOptions) (*ImportSummary, error)
+	// RunImport imports the ports like ImportPorts and records the run, described by run, in the import history.
+	RunImport(ctx context.Context, run ImportRun, options ImportOptions) (*ImportRun, error)
+	ImportRuns(ctx context.Context, limit int) ([]ImportRun, error)
+}
+
+// ImportJobPort (Primary Port) runs imports in the background.
+type ImportJobPort interface {
+	StartImportJob(ctx context.Context, request ImportJobRequest) (*ImportJob, error)
+	FindImportJob(ctx context.Context, id string) (*ImportJob, error)
+	CancelImportJob(ctx context.Context, id string) (*ImportJob, error)
+	// RecoverImportJobs finishes the jobs left running by servers that stopped, now and until Close.
+	RecoverImportJobs(ctx context.Context) error
+	// Close cancels the running jobs and waits until they recorded their state.
+	Close()
 }
 
 // RepositoryPort (Secondary Port)
 type RepositoryPort interface {
-	SaveBulk(ctx context.Context, port []Port) error
+	SaveBulk(ctx context.Context, port []Port) (SaveResult, error)
+	CopyBulk(ctx context.Context, ports []Port) (SaveResult, error)
+	SaveIfVersion(ctx context.Context, port Port, version int64) (int64, error)
 	FindByID(ctx context.Context, id string) (*Port, error)
+	FindByIDs(ctx context.Context, ids []string) (map[string]Port, error)
+	FindByIdentifier(ctx context.Context, identifier string) (*Port, error)
+	FindByCode(ctx context.Context, code string) ([]Port, error)
+	FindNearby(ctx context.Context, query NearbyQuery) ([]NearbyPort, error)
+	List(ctx context.Context, query ListQuery) (*PortPage, error)
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	History(ctx context.Context, portID string) ([]PortChange, error)
+	CountByCountry(ctx context.Context) (map[string]int64, error)
+	Count(ctx context.Context) (int64, error)
+	MissingIDs(ctx context.Context, ids []string) ([]string, error)
+	DeletedIDs(ctx context.Context, ids []string) ([]string, error)
+	Delete(ctx context.Context, id string) error
+	DeleteIfVersion(ctx context.Context, id string, version int64) error
+	DeleteMany(ctx context.Context, ids []string) (int64, error)
+	Restore(ctx context.Context, id string) error
+	RestoreIfVersion(ctx context.Context, id string, version int64) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error
+	FindCheckpoint(ctx context.Context, fingerprint string) (*Checkpoint, error)
+	DeleteCheckpoint(ctx context.Context, fingerprint string) error
+	// InTransaction commits the changes of the repository calls fn makes with its context together,
+	// or none of them when fn fails.
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateImportJob(ctx context.Context, job ImportJob) error
+	// SaveImportJobProgress updates the counters of a running job and tells whether its cancellation was requested.
+	SaveImportJobProgress(ctx context.Context, id string, summary ImportSummary) (bool, error)
+	FinishImportJob(ctx context.Context, job ImportJob) error
+	FindImportJob(ctx context.Context, id string) (*ImportJob, error)
+	RequestImportJobCancel(ctx context.Context, id string) error
+	// FinishStaleImportJobs finishes the running jobs not updated since staleSince, and their runs, with the
+	// given error: canceled when their cancellation was requested, failed otherwise. It returns how many it finished.
+	FinishStaleImportJobs(ctx context.Context, staleSince time.Time, reason string) (int64, error)
+	CreateImportRun(ctx context.Context, run ImportRun) error
+	FinishImportRun(ctx context.Context, run ImportRun) error
+	// FindLastImportRun returns the latest run that imported a file: dry runs and unchanged files are ignored.
+	FindLastImportRun(ctx context.Context) (*ImportRun, error)
+	// ListImportRuns returns the latest runs, newest first.
+	ListImportRuns(ctx context.Context, limit int) ([]ImportRun, error)
 }
 
 // ParserPort (Secondary Port)
 type ParserPort interface {
 	Parse(ctx context.Context) (<-chan Port, <-chan error)
 }
+
+// RejectPort (Secondary Port) records the records an import rejected.
+type RejectPort interface {
+	Reject(ctx context.Context, record RecordError) error
+}
+
+// ProgressPort (Secondary Port) follows the counters of a running import.
+type ProgressPort interface {
+	Progress(ctx context.Context, summary ImportSummary)
+}
+
+// DiffPort (Secondary Port) receives the changes a dry-run import would make.
+type DiffPort interface {
+	Diff(ctx context.Context, diff PortDiff) error
+}
+
+// DigestPort (Secondary Port) measures an import source that is only known once it is read, such as the standard input.
+type DigestPort interface {
+	// Digest reads what is left of the source and returns its checksum ("sha256:<hex>") and size.
+	Digest() (string, int64, error)
+}
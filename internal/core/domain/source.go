@@ -0,0 +1,28 @@
+package domain
+
+import "context"
+
+const (
+	ChangeSourceHTTP   = "http"
+	ChangeSourceImport = "import"
+	ChangeSourceCLI    = "cli"
+)
+
+// ChangeSource identifies who writes ports. It travels in the context down to the repository,
+// which records it in the port history.
+type ChangeSource struct {
+	Kind  string
+	Actor string
+	RunID string
+}
+
+type changeSourceKey struct{}
+
+func WithChangeSource(ctx context.Context, source ChangeSource) context.Context {
+	return context.WithValue(ctx, changeSourceKey{}, source)
+}
+
+func ChangeSourceFromContext(ctx context.Context) ChangeSource {
+	source, _ := ctx.Value(changeSourceKey{}).(ChangeSource)
+	return source
+}
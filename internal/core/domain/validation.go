@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // the runtime image ships without a zoneinfo database
+	"unicode/utf8"
+)
+
+// Column limits of the ports table (see migrations/000001_create_ports_table.up.sql).
+const (
+	maxIDLength       = 50
+	maxNameLength     = 100
+	maxCityLength     = 100
+	maxCountryLength  = 100
+	maxProvinceLength = 100
+	maxTimezoneLength = 50
+	maxCodeLength     = 10
+)
+
+var unlocodePattern = regexp.MustCompile(`^[A-Za-z]{2}[A-Za-z0-9]{3}$`)
+
+// FieldError describes why a single field of a Port is invalid.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError lists every invalid field of a Port. It matches ErrInvalidPort with errors.Is.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", f.Field, f.Reason))
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidPort, strings.Join(reasons, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidPort
+}
+
+func (e *ValidationError) add(field, reason string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// Validate checks the port against the domain rules and returns a *ValidationError
+// listing every invalid field, or nil when the port is valid.
+func (p Port) Validate() error {
+	verr := &ValidationError{}
+
+	if p.ID != nil {
+		if *p.ID == "" {
+			verr.add("id", "must not be empty")
+		}
+		checkLength(verr, "id", *p.ID, maxIDLength)
+	}
+
+	checkLength(verr, "name", p.Name, maxNameLength)
+	checkLength(verr, "city", p.City, maxCityLength)
+	checkLength(verr, "country", p.Country, maxCountryLength)
+	checkLength(verr, "province", p.Province, maxProvinceLength)
+	checkLength(verr, "timezone", p.Timezone, maxTimezoneLength)
+	checkLength(verr, "code", p.Code, maxCodeLength)
+
+	if len(p.Unlocs) == 0 {
+		verr.add("unlocs", "at least one UN/LOCODE is required")
+	}
+	for i, unloc := range p.Unlocs {
+		if !unlocodePattern.MatchString(unloc) {
+			verr.add(fmt.Sprintf("unlocs[%d]", i), "%q is not a UN/LOCODE (2-letter country and 3 alphanumerics)", unloc)
+		} else if p.CountryCode != "" && !unlocBelongsTo(unloc, p.CountryCode) {
+			verr.add(fmt.Sprintf("unlocs[%d]", i), "%q does not belong to country %s (%s)", unloc, p.CountryCode, p.Country)
+		}
+	}
+
+	// coordinates are optional, but when present they are [longitude, latitude]
+	if len(p.Coordinates) > 0 {
+		if len(p.Coordinates) != 2 {
+			verr.add("coordinates", "expected [longitude, latitude], got %d values", len(p.Coordinates))
+		} else {
+			if lon := p.Coordinates[0]; lon < -180 || lon > 180 {
+				verr.add("coordinates[0]", "longitude %v out of range [-180, 180]", lon)
+			}
+			if lat := p.Coordinates[1]; lat < -90 || lat > 90 {
+				verr.add("coordinates[1]", "latitude %v out of range [-90, 90]", lat)
+			}
+		}
+	}
+
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+			verr.add("timezone", "%q is not an IANA time zone", p.Timezone)
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+func checkLength(verr *ValidationError, field, value string, limit int) {
+	if n := utf8.RuneCountInString(value); n > limit {
+		verr.add(field, "length %d exceeds the limit of %d characters", n, limit)
+	}
+}
@@ -0,0 +1,129 @@
+//go:build unit
+
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validPort() Port {
+	id := "AEAJM"
+	return Port{
+		ID:          &id,
+		Name:        "Ajman",
+		City:        "Ajman",
+		Country:     "United Arab Emirates",
+		Alias:       []string{},
+		Regions:     []string{},
+		Coordinates: []float64{55.5136433, 25.4052165},
+		Province:    "Ajman",
+		Timezone:    "Asia/Dubai",
+		Unlocs:      []string{"AEAJM"},
+		Code:        "52000",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid port should return nil", func(t *testing.T) {
+		assert.NoError(t, validPort().Validate())
+	})
+
+	t.Run("port without coordinates and timezone should be valid", func(t *testing.T) {
+		port := validPort()
+		port.Coordinates = nil
+		port.Timezone = ""
+
+		assert.NoError(t, port.Validate())
+	})
+
+	t.Run("port without unlocs should return error", func(t *testing.T) {
+		port := validPort()
+		port.Unlocs = nil
+
+		err := port.Validate()
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidPort))
+
+		var verr *ValidationError
+		require.True(t, errors.As(err, &verr))
+		require.Len(t, verr.Fields, 1)
+		assert.Equal(t, "unlocs", verr.Fields[0].Field)
+	})
+
+	t.Run("every invalid field should be reported", func(t *testing.T) {
+		port := validPort()
+		port.Unlocs = []string{"AEAJM", "AE-JM"}
+		port.Coordinates = []float64{190, -91}
+		port.Timezone = "Mars/Olympus"
+		port.Name = strings.Repeat("a", 101)
+		port.Code = "12345678901"
+
+		err := port.Validate()
+		require.Error(t, err)
+
+		var verr *ValidationError
+		require.True(t, errors.As(err, &verr))
+
+		var fields []string
+		for _, f := range verr.Fields {
+			fields = append(fields, f.Field)
+		}
+		assert.ElementsMatch(t, []string{"name", "code", "unlocs[1]", "coordinates[0]", "coordinates[1]", "timezone"}, fields)
+	})
+
+	t.Run("coordinates with wrong arity should return error", func(t *testing.T) {
+		port := validPort()
+		port.Coordinates = []float64{55.51}
+
+		var verr *ValidationError
+		require.True(t, errors.As(port.Validate(), &verr))
+		assert.Equal(t, "coordinates", verr.Fields[0].Field)
+	})
+
+	t.Run("unloc of another country should return error", func(t *testing.T) {
+		port := validPort()
+		port.CountryCode = "AE"
+		port.Unlocs = []string{"AEAJM", "omsoh"}
+
+		var verr *ValidationError
+		require.True(t, errors.As(port.Validate(), &verr))
+		require.Len(t, verr.Fields, 1)
+		assert.Equal(t, "unlocs[1]", verr.Fields[0].Field)
+	})
+
+	t.Run("unloc of a withdrawn country should belong to its successors", func(t *testing.T) {
+		port := validPort()
+		port.Unlocs = []string{"ANEUX"}
+		for _, code := range []string{"NL", "BQ", "CW", "SX"} {
+			port.CountryCode = code
+			assert.NoError(t, port.Validate(), code)
+		}
+
+		port.CountryCode = "AE"
+		var verr *ValidationError
+		require.True(t, errors.As(port.Validate(), &verr))
+		assert.Equal(t, "unlocs[0]", verr.Fields[0].Field)
+	})
+
+	t.Run("timezone region without a city should return error", func(t *testing.T) {
+		port := validPort()
+		port.Timezone = "America/Argentina"
+
+		var verr *ValidationError
+		require.True(t, errors.As(port.Validate(), &verr))
+		require.Len(t, verr.Fields, 1)
+		assert.Equal(t, "timezone", verr.Fields[0].Field)
+	})
+
+	t.Run("length limits should count characters, not bytes", func(t *testing.T) {
+		port := validPort()
+		port.Province = strings.Repeat("¸", 100)
+
+		assert.NoError(t, port.Validate())
+	})
+}
@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// CSVFields are the port fields a CSV column can be mapped to, in their default column order.
+// Coordinates are read from two columns, latitude and longitude.
+var CSVFields = []string{"id", "name", "city", "country", "alias", "regions", "latitude", "longitude", "province", "timezone", "unlocs", "code"}
+
+// CSVHeader tells whether the first record of a CSV file is a header.
+type CSVHeader int
+
+const (
+	// CSVHeaderAuto treats the first record as a header when one of its cells names a mapped column.
+	CSVHeaderAuto CSVHeader = iota
+	CSVHeaderPresent
+	CSVHeaderAbsent
+)
+
+// CSVOptions configures how the records of a CSV file map to ports.
+type CSVOptions struct {
+	// Delimiter separates the cells of a record, ',' when zero.
+	Delimiter rune
+	// ValueDelimiter separates the values of alias, regions and unlocs inside a cell, "|" when empty.
+	ValueDelimiter string
+	// Columns maps a field of CSVFields to a header name or to a 1-based column number.
+	// Unmapped fields are read from the header of the same name, or from their CSVFields
+	// position when the file has no header.
+	Columns map[string]string
+	Header  CSVHeader
+}
+
+type csvParser struct {
+	reader  io.Reader
+	options CSVOptions
+}
+
+// NewCSVParser reads one port per record. The port ID is the id column, or the first UN/LOCODE
+// when the file has none.
+func NewCSVParser(reader io.Reader, options CSVOptions) domain.ParserPort {
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	if options.ValueDelimiter == "" {
+		options.ValueDelimiter = "|"
+	}
+
+	return &csvParser{reader, options}
+}
+
+func (p *csvParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan error) {
+	portCh := make(chan domain.Port)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(portCh)
+		defer close(errCh)
+
+		input, bom := skipBOM(p.reader)
+		reader := csv.NewReader(input)
+		reader.Comma = p.options.Delimiter
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		reader.ReuseRecord = true
+
+		first, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "error to read csv record", "error", err)
+			errCh <- fmt.Errorf("%w: %v", domain.ErrInvalidCsv, err)
+			return
+		}
+
+		var header []string
+		if p.hasHeader(first) {
+			header = append([]string(nil), first...)
+		}
+		positions, err := p.columnPositions(header)
+		if err != nil {
+			slog.ErrorContext(ctx, "error to map csv columns", "error", err)
+			errCh <- err
+			return
+		}
+
+		record, offset := first, bom
+		if header != nil {
+			record = nil
+		}
+		for {
+			if record == nil {
+				offset = bom + reader.InputOffset()
+				if record, err = reader.Read(); errors.Is(err, io.EOF) {
+					return
+				}
+				if err != nil {
+					slog.ErrorContext(ctx, "error to read csv record", "error", err)
+					errCh <- fmt.Errorf("%w: %v", domain.ErrInvalidCsv, err)
+					return
+				}
+			}
+
+			port, err := p.toPort(record, positions)
+			port.Offset = offset
+			record = nil
+			if err != nil {
+				row, _ := reader.FieldPos(0)
+				slog.ErrorContext(ctx, "error to decode csv record", "line", row, "error", err)
+				if !reject(ctx, errCh, port, fmt.Sprintf("line %d", row), fmt.Errorf("%w: line %d: %v", domain.ErrInvalidCsv, row, err)) {
+					return
+				}
+				continue
+			}
+
+			select {
+			case portCh <- port:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return portCh, errCh
+}
+
+func (p *csvParser) hasHeader(first []string) bool {
+	switch p.options.Header {
+	case CSVHeaderPresent:
+		return true
+	case CSVHeaderAbsent:
+		return false
+	}
+
+	names := map[string]bool{}
+	for _, field := range CSVFields {
+		names[field] = true
+	}
+	for _, column := range p.options.Columns {
+		if _, err := strconv.Atoi(column); err != nil {
+			names[strings.ToLower(column)] = true
+		}
+	}
+	for _, cell := range first {
+		if names[strings.ToLower(strings.TrimSpace(cell))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// columnPositions returns the 0-based column of every mapped field, fields without a column are left out.
+func (p *csvParser) columnPositions(header []string) (map[string]int, error) {
+	known := map[string]bool{}
+	for _, field := range CSVFields {
+		known[field] = true
+	}
+	for field := range p.options.Columns {
+		if !known[field] {
+			return nil, fmt.Errorf("%w: unknown field %q, expected one of %s", domain.ErrInvalidCsv, field, strings.Join(CSVFields, ", "))
+		}
+	}
+
+	byName := map[string]int{}
+	for i, name := range header {
+		byName[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	positions := map[string]int{}
+	for i, field := range CSVFields {
+		column, mapped := p.options.Columns[field]
+		if n, err := strconv.Atoi(column); mapped && err == nil {
+			if n < 1 {
+				return nil, fmt.Errorf("%w: column number of %s must be at least 1", domain.ErrInvalidCsv, field)
+			}
+			positions[field] = n - 1
+			continue
+		}
+
+		if header == nil {
+			if mapped {
+				return nil, fmt.Errorf("%w: the file has no header, map %s to a column number", domain.ErrInvalidCsv, field)
+			}
+			positions[field] = i
+			continue
+		}
+
+		if !mapped {
+			column = field
+		}
+		position, found := byName[strings.ToLower(column)]
+		if !found && mapped {
+			return nil, fmt.Errorf("%w: column %q of %s not found in the header", domain.ErrInvalidCsv, column, field)
+		}
+		if found {
+			positions[field] = position
+		}
+	}
+
+	return positions, nil
+}
+
+func (p *csvParser) toPort(record []string, positions map[string]int) (domain.Port, error) {
+	cell := func(field string) string {
+		position, ok := positions[field]
+		if !ok || position >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[position])
+	}
+
+	port := domain.Port{
+		Name:     cell("name"),
+		City:     cell("city"),
+		Country:  cell("country"),
+		Alias:    p.splitValues(cell("alias")),
+		Regions:  p.splitValues(cell("regions")),
+		Province: cell("province"),
+		Timezone: cell("timezone"),
+		Unlocs:   p.splitValues(cell("unlocs")),
+		Code:     cell("code"),
+	}
+
+	latitude, longitude := cell("latitude"), cell("longitude")
+	if latitude != "" || longitude != "" {
+		lat, err := strconv.ParseFloat(latitude, 64)
+		if err != nil {
+			return port, fmt.Errorf("latitude %q is not a number", latitude)
+		}
+		lon, err := strconv.ParseFloat(longitude, 64)
+		if err != nil {
+			return port, fmt.Errorf("longitude %q is not a number", longitude)
+		}
+		port.Coordinates = []float64{lon, lat}
+	}
+
+	if id := cell("id"); id != "" {
+		port.ID = &id
+	}
+	ensureID(&port)
+
+	return port, nil
+}
+
+func (p *csvParser) splitValues(cell string) []string {
+	values := []string{}
+	for _, value := range strings.Split(cell, p.options.ValueDelimiter) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// skipBOM drops the byte order mark spreadsheet tools write at the start of UTF-8 files. It returns how
+// many bytes it dropped, the parsers add them to the offsets they report so that they stay file offsets.
+func skipBOM(reader io.Reader) (io.Reader, int64) {
+	buffered := bufio.NewReader(reader)
+	r, size, err := buffered.ReadRune()
+	if err == nil && r == '\uFEFF' {
+		return buffered, int64(size)
+	}
+	if err == nil {
+		_ = buffered.UnreadRune()
+	}
+	return buffered, 0
+}
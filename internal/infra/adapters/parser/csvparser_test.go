@@ -0,0 +1,124 @@
+//go:build unit
+
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseAll(t *testing.T, parser domain.ParserPort) ([]domain.Port, error) {
+	t.Helper()
+
+	portCh, errCh := parser.Parse(context.Background())
+
+	// the parsers go on after a rejected record, both channels are read until they are closed
+	var ports []domain.Port
+	var errs []error
+	for portCh != nil || errCh != nil {
+		select {
+		case port, ok := <-portCh:
+			if !ok {
+				portCh = nil
+				continue
+			}
+			ports = append(ports, port)
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return ports, errors.Join(errs...)
+}
+
+func TestCSVParser(t *testing.T) {
+	t.Run("csv with header should map columns by name", func(t *testing.T) {
+		csvData := "\ufeffname,city,country,alias,regions,latitude,longitude,province,timezone,unlocs,code\n" +
+			"Ajman,Ajman,United Arab Emirates,,,25.4052165,55.5136433,Ajman,Asia/Dubai,AEAJM,52000\n" +
+			"\"Dalian, Liaoning\",Dalian,China,Dairen|Lüda,,38.92,121.62,Liaoning,Asia/Shanghai,cndlc|CNDAL,57000\n"
+
+		ports, err := parseAll(t, NewCSVParser(strings.NewReader(csvData), CSVOptions{}))
+		require.NoError(t, err)
+		require.Len(t, ports, 2)
+
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, "Ajman", ports[0].Name)
+		assert.Equal(t, []string{}, ports[0].Alias)
+		assert.Equal(t, []float64{55.5136433, 25.4052165}, ports[0].Coordinates)
+		assert.Equal(t, "Asia/Dubai", ports[0].Timezone)
+		assert.Equal(t, "52000", ports[0].Code)
+		assert.Equal(t, int64(strings.Index(csvData, "Ajman")), ports[0].Offset)
+
+		assert.Equal(t, "CNDLC", *ports[1].ID)
+		assert.Equal(t, "Dalian, Liaoning", ports[1].Name)
+		assert.Equal(t, []string{"Dairen", "Lüda"}, ports[1].Alias)
+		assert.Equal(t, []string{"cndlc", "CNDAL"}, ports[1].Unlocs)
+	})
+
+	t.Run("csv with custom columns and delimiters should be mapped", func(t *testing.T) {
+		csvData := "LOCODE;Port Name;Nation;Other LOCODEs\n" +
+			"AEAJM;Ajman;United Arab Emirates;AEAJM/AEAJ2\n"
+
+		parser := NewCSVParser(strings.NewReader(csvData), CSVOptions{
+			Delimiter:      ';',
+			ValueDelimiter: "/",
+			Columns:        map[string]string{"id": "locode", "name": "Port Name", "country": "Nation", "unlocs": "4"},
+		})
+		ports, err := parseAll(t, parser)
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, "Ajman", ports[0].Name)
+		assert.Equal(t, "United Arab Emirates", ports[0].Country)
+		assert.Equal(t, []string{"AEAJM", "AEAJ2"}, ports[0].Unlocs)
+		assert.Nil(t, ports[0].Coordinates)
+	})
+
+	t.Run("csv without header should map columns by position", func(t *testing.T) {
+		csvData := "AEAJM,Ajman,Ajman,United Arab Emirates,,,25.40,55.51,Ajman,Asia/Dubai,AEAJM,52000\n"
+
+		ports, err := parseAll(t, NewCSVParser(strings.NewReader(csvData), CSVOptions{}))
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, "Asia/Dubai", ports[0].Timezone)
+		assert.Equal(t, []float64{55.51, 25.40}, ports[0].Coordinates)
+	})
+
+	t.Run("csv without header should require column numbers", func(t *testing.T) {
+		parser := NewCSVParser(strings.NewReader("AEAJM,Ajman\n"), CSVOptions{
+			Header:  CSVHeaderAbsent,
+			Columns: map[string]string{"name": "Port Name"},
+		})
+
+		_, err := parseAll(t, parser)
+		assert.ErrorIs(t, err, domain.ErrInvalidCsv)
+	})
+
+	t.Run("csv with unknown field in the mapping should return error", func(t *testing.T) {
+		parser := NewCSVParser(strings.NewReader("name\nAjman\n"), CSVOptions{Columns: map[string]string{"harbour": "name"}})
+
+		_, err := parseAll(t, parser)
+		assert.ErrorIs(t, err, domain.ErrInvalidCsv)
+	})
+
+	t.Run("csv with invalid coordinates should return error with the line", func(t *testing.T) {
+		csvData := "unlocs,latitude,longitude\nAEAJM,25.40,55.51\nAEDXB,north,55.27\n"
+
+		ports, err := parseAll(t, NewCSVParser(strings.NewReader(csvData), CSVOptions{}))
+		assert.Len(t, ports, 1)
+		assert.ErrorIs(t, err, domain.ErrInvalidCsv)
+		assert.ErrorContains(t, err, "line 3")
+	})
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes of the compressed streams Decompress recognizes.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic = []byte("BZh")
+)
+
+// Decompress detects a gzip, zstd or bzip2 stream from its magic bytes and returns a reader of the
+// decompressed data, any other stream is returned as it is. Closing the reader releases the decoder,
+// not the underlying reader.
+func Decompress(reader io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		decoder, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip stream: %w", err)
+		}
+		return decoder, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd stream: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) == 4 && magic[3] >= '1' && magic[3] <= '9':
+		// the fourth byte is the block size, it tells a bzip2 stream from text starting with "BZh"
+		return io.NopCloser(bzip2.NewReader(buffered)), nil
+	}
+
+	return io.NopCloser(buffered), nil
+}
@@ -0,0 +1,81 @@
+//go:build unit
+
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const decompressData = `{"AEAJM": {"name": "Ajman", "unlocs": ["AEAJM"]}}`
+
+// bzip2Data is decompressData compressed with bzip2, the standard library has no bzip2 writer.
+var bzip2Data = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x64, 0x3d, 0x0f, 0x14, 0x00, 0x00,
+	0x16, 0x9f, 0x80, 0x50, 0x04, 0x00, 0x10, 0x22, 0x12, 0x00, 0x0a, 0x2a, 0x17, 0x8a, 0x0a, 0x20,
+	0x00, 0x21, 0xa9, 0xa7, 0xaa, 0x62, 0x66, 0x81, 0x3d, 0x34, 0x85, 0x1a, 0x32, 0x06, 0x8d, 0x32,
+	0x34, 0x25, 0x06, 0xf0, 0xd6, 0x7b, 0xfa, 0x4c, 0x6a, 0x20, 0xa1, 0x14, 0x0b, 0x8f, 0x0d, 0x9b,
+	0x89, 0x32, 0xdf, 0x09, 0xbb, 0xb9, 0xea, 0xa7, 0x7f, 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0x64,
+	0x3d, 0x0f, 0x14,
+}
+
+func TestDecompress(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, err := gz.Write([]byte(decompressData))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstded := encoder.EncodeAll([]byte(decompressData), nil)
+
+	tests := map[string][]byte{
+		"gzip":  gzipped.Bytes(),
+		"zstd":  zstded,
+		"bzip2": bzip2Data,
+		"plain": []byte(decompressData),
+	}
+	for name, input := range tests {
+		t.Run(name+" stream should be read decompressed", func(t *testing.T) {
+			reader, err := Decompress(bytes.NewReader(input))
+			require.NoError(t, err)
+			defer reader.Close()
+
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, decompressData, string(data))
+		})
+	}
+
+	t.Run("short plain stream should be returned as it is", func(t *testing.T) {
+		reader, err := Decompress(strings.NewReader("BZh"))
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "BZh", string(data))
+	})
+
+	t.Run("compressed stream should feed a parser", func(t *testing.T) {
+		reader, err := Decompress(bytes.NewReader(gzipped.Bytes()))
+		require.NoError(t, err)
+
+		ports, err := parseAll(t, NewJSONParser(reader))
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+		assert.Equal(t, "Ajman", ports[0].Name)
+	})
+
+	t.Run("truncated gzip header should return error", func(t *testing.T) {
+		_, err := Decompress(bytes.NewReader(gzipped.Bytes()[:5]))
+		assert.Error(t, err)
+	})
+}
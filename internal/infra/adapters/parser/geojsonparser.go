@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+type geoJSONParser struct {
+	reader io.Reader
+}
+
+// NewGeoJSONParser reads the features of a GeoJSON FeatureCollection one at a time. The Point geometry
+// gives the coordinates and the properties the other fields of the port. The port ID is the id property,
+// then the feature id, then the first UN/LOCODE.
+func NewGeoJSONParser(reader io.Reader) domain.ParserPort {
+	return &geoJSONParser{
+		reader,
+	}
+}
+
+type geoJSONFeature struct {
+	Type     string          `json:"type"`
+	ID       interface{}     `json:"id"`
+	Geometry *geoJSONPoint   `json:"geometry"`
+	Props    json.RawMessage `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func (p *geoJSONParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan error) {
+	portCh := make(chan domain.Port)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(portCh)
+		defer close(errCh)
+
+		input, bom := skipBOM(p.reader)
+		decoder := json.NewDecoder(input)
+
+		token, err := decoder.Token()
+		if err != nil {
+			slog.ErrorContext(ctx, "error to read initial token", "error", err)
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '{' {
+			slog.ErrorContext(ctx, "error to read initial token, expected an object")
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				slog.ErrorContext(ctx, "error to read key", "error", err)
+				errCh <- domain.ErrInvalidJson
+				return
+			}
+
+			switch keyToken {
+			case "type":
+				var collectionType string
+				if err := decoder.Decode(&collectionType); err != nil || collectionType != "FeatureCollection" {
+					slog.ErrorContext(ctx, "geojson document is not a FeatureCollection", "type", collectionType)
+					errCh <- fmt.Errorf("%w: expected a FeatureCollection", domain.ErrInvalidJson)
+					return
+				}
+			case "features":
+				if err := p.parseFeatures(ctx, decoder, bom, portCh, errCh); err != nil {
+					errCh <- err
+					return
+				}
+			default:
+				// bbox, crs and foreign members are not needed
+				var skipped json.RawMessage
+				if err := decoder.Decode(&skipped); err != nil {
+					slog.ErrorContext(ctx, "error to decode member", "key", keyToken, "error", err)
+					errCh <- domain.ErrInvalidJson
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			slog.ErrorContext(ctx, "error to read last token", "error", err)
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '}' {
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+	}()
+
+	return portCh, errCh
+}
+
+func (p *geoJSONParser) parseFeatures(ctx context.Context, decoder *json.Decoder, bom int64, portCh chan<- domain.Port, errCh chan<- error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		slog.ErrorContext(ctx, "error to read features", "error", err)
+		return domain.ErrInvalidJson
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		slog.ErrorContext(ctx, "features should be an array")
+		return fmt.Errorf("%w: features should be an array", domain.ErrInvalidJson)
+	}
+
+	for index := 0; decoder.More(); index++ {
+		offset := bom + decoder.InputOffset()
+		var feature geoJSONFeature
+		err := decoder.Decode(&feature)
+		if err != nil && !skippable(err) {
+			slog.ErrorContext(ctx, "error to decode feature", "index", index, "error", err)
+			return fmt.Errorf("%w: feature %d: %v", domain.ErrInvalidJson, index, err)
+		}
+
+		var port domain.Port
+		if err == nil {
+			port, err = feature.toPort()
+		}
+		port.Offset = offset
+		if err != nil {
+			slog.ErrorContext(ctx, "error to decode feature", "index", index, "error", err)
+			if !reject(ctx, errCh, port, fmt.Sprintf("feature %d", index), fmt.Errorf("%w: feature %d: %v", domain.ErrInvalidJson, index, err)) {
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case portCh <- port:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		slog.ErrorContext(ctx, "error to read end of features", "error", err)
+		return domain.ErrInvalidJson
+	}
+
+	return nil
+}
+
+func (f geoJSONFeature) toPort() (domain.Port, error) {
+	var port domain.Port
+	if f.Type != "Feature" {
+		return port, fmt.Errorf("type %q is not Feature", f.Type)
+	}
+	if len(f.Props) > 0 && string(f.Props) != "null" {
+		if err := json.Unmarshal(f.Props, &port); err != nil {
+			return port, fmt.Errorf("invalid properties: %v", err)
+		}
+	}
+
+	port.Coordinates = nil
+	if f.Geometry != nil {
+		if f.Geometry.Type != "Point" {
+			return port, fmt.Errorf("geometry %q is not a Point", f.Geometry.Type)
+		}
+		// a third position is the altitude, ports only keep longitude and latitude
+		if len(f.Geometry.Coordinates) < 2 {
+			return port, fmt.Errorf("point should have a longitude and a latitude")
+		}
+		port.Coordinates = f.Geometry.Coordinates[:2]
+	}
+
+	if port.ID == nil || *port.ID == "" {
+		switch id := f.ID.(type) {
+		case string:
+			port.ID = &id
+		case float64:
+			formatted := fmt.Sprint(id)
+			port.ID = &formatted
+		}
+	}
+	ensureID(&port)
+
+	return port, nil
+}
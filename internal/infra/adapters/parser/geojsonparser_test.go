@@ -0,0 +1,72 @@
+//go:build unit
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoJSONParser(t *testing.T) {
+	t.Run("feature collection should return one port per feature", func(t *testing.T) {
+		geoJSONData := `{
+		"type": "FeatureCollection",
+		"bbox": [54.0, 24.0, 56.0, 26.0],
+		"features": [
+			{
+				"type": "Feature",
+				"id": "AEAJM",
+				"geometry": {"type": "Point", "coordinates": [55.5136433, 25.4052165, 3.0]},
+				"properties": {"name": "Ajman", "country": "United Arab Emirates", "timezone": "Asia/Dubai", "unlocs": ["AEAJM"]}
+			},
+			{
+				"type": "Feature",
+				"geometry": null,
+				"properties": {"name": "Abu Dhabi", "unlocs": ["aeauh"], "coordinates": [1, 2]}
+			},
+			{
+				"type": "Feature",
+				"id": "ignored",
+				"geometry": {"type": "Point", "coordinates": [55.27, 25.25]},
+				"properties": {"id": "AEDXB", "name": "Dubai", "unlocs": ["AEDXB"]}
+			}
+		]
+	}`
+
+		ports, err := parseAll(t, NewGeoJSONParser(strings.NewReader(geoJSONData)))
+		require.NoError(t, err)
+		require.Len(t, ports, 3)
+
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, "Ajman", ports[0].Name)
+		assert.Equal(t, "Asia/Dubai", ports[0].Timezone)
+		assert.Equal(t, []float64{55.5136433, 25.4052165}, ports[0].Coordinates)
+
+		assert.Equal(t, "AEAUH", *ports[1].ID)
+		assert.Nil(t, ports[1].Coordinates)
+
+		assert.Equal(t, "AEDXB", *ports[2].ID)
+		assert.Equal(t, []float64{55.27, 25.25}, ports[2].Coordinates)
+	})
+
+	t.Run("non point geometry should return error", func(t *testing.T) {
+		geoJSONData := `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": []}, "properties": {"unlocs": ["AEAJM"]}}
+		]}`
+
+		_, err := parseAll(t, NewGeoJSONParser(strings.NewReader(geoJSONData)))
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+		assert.ErrorContains(t, err, "feature 0")
+	})
+
+	t.Run("single feature should return error", func(t *testing.T) {
+		geoJSONData := `{"type": "Feature", "geometry": null, "properties": {}}`
+
+		_, err := parseAll(t, NewGeoJSONParser(strings.NewReader(geoJSONData)))
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+	})
+}
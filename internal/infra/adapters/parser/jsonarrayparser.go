@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+type jsonArrayParser struct {
+	reader io.Reader
+}
+
+// NewJSONArrayParser reads a top-level JSON array of port objects, one element at a time.
+// The port ID is the id field, or the first UN/LOCODE when the object has none.
+func NewJSONArrayParser(reader io.Reader) domain.ParserPort {
+	return &jsonArrayParser{
+		reader,
+	}
+}
+
+func (p *jsonArrayParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan error) {
+	portCh := make(chan domain.Port)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(portCh)
+		defer close(errCh)
+
+		input, bom := skipBOM(p.reader)
+		decoder := json.NewDecoder(input)
+
+		token, err := decoder.Token()
+		if err != nil {
+			slog.ErrorContext(ctx, "error to read initial token", "error", err)
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			slog.ErrorContext(ctx, "error to read initial token, expected an array")
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+
+		for index := 0; decoder.More(); index++ {
+			port := domain.Port{Offset: bom + decoder.InputOffset()}
+			if err := decoder.Decode(&port); err != nil {
+				slog.ErrorContext(ctx, "error to decode array element", "index", index, "error", err)
+				recordErr := fmt.Errorf("%w: element %d: %v", domain.ErrInvalidJson, index, err)
+				if !skippable(err) {
+					errCh <- recordErr
+					return
+				}
+				if !reject(ctx, errCh, port, fmt.Sprintf("element %d", index), recordErr) {
+					return
+				}
+				continue
+			}
+			ensureID(&port)
+
+			select {
+			case portCh <- port:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			slog.ErrorContext(ctx, "error to read last token", "error", err)
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != ']' {
+			errCh <- domain.ErrInvalidJson
+			return
+		}
+	}()
+
+	return portCh, errCh
+}
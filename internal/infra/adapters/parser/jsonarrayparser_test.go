@@ -0,0 +1,49 @@
+//go:build unit
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArrayParser(t *testing.T) {
+	t.Run("array of ports should return every element", func(t *testing.T) {
+		jsonData := `[
+		{"id": "AEAJM", "name": "Ajman", "timezone": "Asia/Dubai", "unlocs": ["AEAJM"]},
+		{"name": "Abu Dhabi", "unlocs": ["aeauh"]}
+	]`
+
+		ports, err := parseAll(t, NewJSONArrayParser(strings.NewReader(jsonData)))
+		require.NoError(t, err)
+		require.Len(t, ports, 2)
+
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, "Asia/Dubai", ports[0].Timezone)
+		assert.Equal(t, "AEAUH", *ports[1].ID)
+		assert.Equal(t, "Abu Dhabi", ports[1].Name)
+	})
+
+	t.Run("keyed object should return error", func(t *testing.T) {
+		_, err := parseAll(t, NewJSONArrayParser(strings.NewReader(`{"AEAJM": {"name": "Ajman"}}`)))
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+	})
+
+	t.Run("invalid element should return error with its index", func(t *testing.T) {
+		jsonData := `[{"unlocs": ["AEAJM"]}, {"unlocs": "AEAUH"}]`
+
+		ports, err := parseAll(t, NewJSONArrayParser(strings.NewReader(jsonData)))
+		assert.Len(t, ports, 1)
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+		assert.ErrorContains(t, err, "element 1")
+	})
+
+	t.Run("array without closing bracket should return error", func(t *testing.T) {
+		_, err := parseAll(t, NewJSONArrayParser(strings.NewReader(`[{"unlocs": ["AEAJM"]}`)))
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+	})
+}
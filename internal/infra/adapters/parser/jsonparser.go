@@ -3,6 +3,7 @@ package parser
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 
@@ -42,6 +43,8 @@ func (p *jsonParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan erro
 		}
 
 		for decoder.More() {
+			offset := decoder.InputOffset()
+
 			// reading keys to read line by line
 			keyToken, err := decoder.Token()
 			if err != nil {
@@ -57,15 +60,27 @@ func (p *jsonParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan erro
 				return
 			}
 
-			var port domain.Port
+			port := domain.Port{Offset: offset}
 			if err := decoder.Decode(&port); err != nil {
 				slog.ErrorContext(ctx, "error to decode value to the key", "key", key, "error", err)
-				errCh <- domain.ErrInvalidJson
-				return
+				recordErr := fmt.Errorf("%w: key %s: %v", domain.ErrInvalidJson, key, err)
+				port.ID = &key
+				if !skippable(err) {
+					errCh <- recordErr
+					return
+				}
+				if !reject(ctx, errCh, port, key, recordErr) {
+					return
+				}
+				continue
 			}
 
 			port.ID = &key
-			portCh <- port
+			select {
+			case portCh <- port:
+			case <-ctx.Done():
+				return
+			}
 		}
 
 		token, err = decoder.Token()
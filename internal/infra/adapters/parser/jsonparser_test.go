@@ -125,4 +125,18 @@ func TestJSONParser(t *testing.T) {
 			t.Fatal("expected an error but got a port")
 		}
 	})
+
+	t.Run("value of the wrong type should be rejected and the next ports read", func(t *testing.T) {
+		jsonData := `{"AEAJM": {"unlocs": "AEAJM"}, "AEDXB": {"unlocs": ["AEDXB"]}}`
+
+		ports, err := parseAll(t, NewJSONParser(strings.NewReader(jsonData)))
+		require.Len(t, ports, 1)
+		assert.Equal(t, "AEDXB", *ports[0].ID)
+
+		var recordErr *domain.RecordError
+		require.ErrorAs(t, err, &recordErr)
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+		assert.Equal(t, "AEAJM", recordErr.Key)
+		assert.Equal(t, int64(1), recordErr.Offset)
+	})
 }
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+type ndjsonParser struct {
+	reader io.Reader
+}
+
+// NewNDJSONParser reads newline-delimited JSON (JSON Lines), one port object per line. Blank lines are skipped.
+// The port ID is the id field, or the first UN/LOCODE when the object has none.
+func NewNDJSONParser(reader io.Reader) domain.ParserPort {
+	return &ndjsonParser{
+		reader,
+	}
+}
+
+func (p *ndjsonParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan error) {
+	portCh := make(chan domain.Port)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(portCh)
+		defer close(errCh)
+
+		// a bufio.Reader rather than a bufio.Scanner, lines are not limited in size
+		input, offset := skipBOM(p.reader)
+		reader := bufio.NewReader(input)
+		for line := 1; ; line++ {
+			raw, err := reader.ReadBytes('\n')
+			start := offset
+			offset += int64(len(raw))
+			if err != nil && !errors.Is(err, io.EOF) {
+				slog.ErrorContext(ctx, "error to read line", "line", line, "error", err)
+				errCh <- fmt.Errorf("%w: line %d: %v", domain.ErrInvalidJson, line, err)
+				return
+			}
+
+			if raw = bytes.TrimSpace(raw); len(raw) > 0 {
+				port := domain.Port{Offset: start}
+				if decodeErr := json.Unmarshal(raw, &port); decodeErr != nil {
+					// every line is a document of its own, the next one can still be read
+					slog.ErrorContext(ctx, "error to decode line", "line", line, "error", decodeErr)
+					if !reject(ctx, errCh, port, fmt.Sprintf("line %d", line), fmt.Errorf("%w: line %d: %v", domain.ErrInvalidJson, line, decodeErr)) {
+						return
+					}
+				} else {
+					ensureID(&port)
+
+					select {
+					case portCh <- port:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			if errors.Is(err, io.EOF) {
+				return
+			}
+		}
+	}()
+
+	return portCh, errCh
+}
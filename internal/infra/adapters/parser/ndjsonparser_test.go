@@ -0,0 +1,81 @@
+//go:build unit
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONParser(t *testing.T) {
+	t.Run("json lines should return one port per line", func(t *testing.T) {
+		ndjsonData := `{"id": "AEAJM", "name": "Ajman", "coordinates": [55.5136433, 25.4052165], "unlocs": ["AEAJM"], "code": "52000"}
+
+{"name": "Abu Dhabi", "unlocs": ["aeauh", "AEAB2"]}
+{"id": "", "name": "Dubai", "unlocs": ["AEDXB"]}`
+
+		ports, err := parseAll(t, NewNDJSONParser(strings.NewReader(ndjsonData)))
+		require.NoError(t, err)
+		require.Len(t, ports, 3)
+
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, []float64{55.5136433, 25.4052165}, ports[0].Coordinates)
+		assert.Equal(t, "52000", ports[0].Code)
+		assert.Equal(t, "AEAUH", *ports[1].ID)
+		assert.Equal(t, "Abu Dhabi", ports[1].Name)
+		assert.Equal(t, "AEDXB", *ports[2].ID)
+	})
+
+	t.Run("invalid line should be rejected with the line number and the next lines read", func(t *testing.T) {
+		ndjsonData := "{\"unlocs\": [\"AEAJM\"]}\n{\"unlocs\": [\"AEAUH\"]\n{\"unlocs\": [\"AEDXB\"]}\n"
+
+		ports, err := parseAll(t, NewNDJSONParser(strings.NewReader(ndjsonData)))
+		require.Len(t, ports, 2)
+		assert.Equal(t, "AEDXB", *ports[1].ID)
+		assert.Equal(t, int64(43), ports[1].Offset)
+		assert.ErrorIs(t, err, domain.ErrInvalidJson)
+		assert.ErrorContains(t, err, "line 2")
+
+		var recordErr *domain.RecordError
+		require.ErrorAs(t, err, &recordErr)
+		assert.Equal(t, "line 2", recordErr.Key)
+		assert.True(t, recordErr.Unkeyed)
+		assert.Equal(t, int64(22), recordErr.Offset)
+	})
+
+	t.Run("offsets should count the byte order mark", func(t *testing.T) {
+		ndjsonData := "\ufeff{\"unlocs\": [\"AEAJM\"]}\n{\"unlocs\": [\"AEAUH\"]\n"
+
+		ports, err := parseAll(t, NewNDJSONParser(strings.NewReader(ndjsonData)))
+		require.Len(t, ports, 1)
+		assert.Equal(t, int64(3), ports[0].Offset)
+
+		var recordErr *domain.RecordError
+		require.ErrorAs(t, err, &recordErr)
+		assert.Equal(t, int64(25), recordErr.Offset)
+	})
+
+	t.Run("line of the wrong type should be rejected with its id", func(t *testing.T) {
+		ndjsonData := "{\"id\": \"AEAJM\", \"coordinates\": \"55.51,25.40\"}\n{\"unlocs\": [\"AEDXB\"]}\n"
+
+		ports, err := parseAll(t, NewNDJSONParser(strings.NewReader(ndjsonData)))
+		require.Len(t, ports, 1)
+
+		var recordErr *domain.RecordError
+		require.ErrorAs(t, err, &recordErr)
+		assert.Equal(t, "AEAJM", recordErr.Key)
+		assert.False(t, recordErr.Unkeyed)
+		assert.Equal(t, int64(0), recordErr.Offset)
+	})
+
+	t.Run("port without id nor unlocs should keep a nil id", func(t *testing.T) {
+		ports, err := parseAll(t, NewNDJSONParser(strings.NewReader(`{"name": "Nowhere"}`)))
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+		assert.Nil(t, ports[0].ID)
+	})
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// ensureID gives a port without ID its first UN/LOCODE as ID, like service.CreateOrUpdate does.
+func ensureID(port *domain.Port) {
+	if port.ID != nil && *port.ID != "" {
+		return
+	}
+
+	port.ID = nil
+	if len(port.Unlocs) > 0 {
+		id := strings.ToUpper(port.Unlocs[0])
+		port.ID = &id
+	}
+}
+
+// reject reports a record the parser skipped as a *domain.RecordError and lets the parser go on, the
+// import decides whether to stop. The key is the port ID when the record has one, else position.
+// It returns false when ctx is done.
+func reject(ctx context.Context, errCh chan<- error, port domain.Port, position string, err error) bool {
+	key, unkeyed := position, true
+	if ensureID(&port); port.ID != nil {
+		key, unkeyed = *port.ID, false
+	}
+
+	select {
+	case errCh <- &domain.RecordError{Key: key, Unkeyed: unkeyed, Offset: port.Offset, Err: err}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// skippable tells whether a json.Decoder can go on after err: a value of the wrong type is consumed
+// whole, a syntax error leaves the decoder in the middle of the document.
+func skippable(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &typeErr)
+}
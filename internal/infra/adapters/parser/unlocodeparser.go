@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/core/domain/iso3166"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Columns of the UNECE UN/LOCODE code list CSV files (CodeListPart1.csv, ...).
+const (
+	unlocodeChange = iota
+	unlocodeCountry
+	unlocodeLocation
+	unlocodeName
+	unlocodeNameWoDiacritics
+	unlocodeSubdivision
+	unlocodeStatus
+	unlocodeFunction
+	unlocodeDate
+	unlocodeIATA
+	unlocodeCoordinates
+	unlocodeRemarks
+)
+
+// UNLOCODESeaport is the function code of the locations with a port.
+const UNLOCODESeaport = "1"
+
+type unlocodeParser struct {
+	reader    io.Reader
+	functions string
+}
+
+// NewUNLOCODEParser reads a UNECE UN/LOCODE code list in CSV format. Only the locations with one of the
+// given function codes are kept (e.g. UNLOCODESeaport, or "14" for seaports and airports), all of them
+// when functions is empty. Entries marked for deletion ("X") and references ("=") are skipped.
+func NewUNLOCODEParser(reader io.Reader, functions string) domain.ParserPort {
+	return &unlocodeParser{reader, functions}
+}
+
+func (p *unlocodeParser) Parse(ctx context.Context) (<-chan domain.Port, <-chan error) {
+	portCh := make(chan domain.Port)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(portCh)
+		defer close(errCh)
+
+		input, bom := skipBOM(p.reader)
+		reader := csv.NewReader(input)
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+
+		// country names of the ".NAME" rows, for the countries missing from ISO 3166
+		countryNames := map[string]string{}
+		for {
+			offset := bom + reader.InputOffset()
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "error to read unlocode record", "error", err)
+				errCh <- fmt.Errorf("%w: %v", domain.ErrInvalidCsv, err)
+				return
+			}
+			if len(record) <= unlocodeCoordinates {
+				row, _ := reader.FieldPos(0)
+				slog.ErrorContext(ctx, "unlocode record with missing columns", "line", row, "columns", len(record))
+				err := fmt.Errorf("%w: line %d: expected at least %d columns, got %d", domain.ErrInvalidCsv, row, unlocodeCoordinates+1, len(record))
+				if !reject(ctx, errCh, domain.Port{Offset: offset}, fmt.Sprintf("line %d", row), err) {
+					return
+				}
+				continue
+			}
+			for i := range record {
+				record[i] = strings.TrimSpace(toUTF8(record[i]))
+			}
+
+			country, location := strings.ToUpper(record[unlocodeCountry]), strings.ToUpper(record[unlocodeLocation])
+			switch {
+			case strings.EqualFold(country, "country"):
+				// header row of the redistributed copies, the official files have none
+				continue
+			case record[unlocodeChange] == "X" || record[unlocodeChange] == "=":
+				continue
+			case location == "":
+				countryNames[country] = strings.TrimPrefix(record[unlocodeName], ".")
+				continue
+			case !p.hasFunction(record[unlocodeFunction]):
+				continue
+			}
+
+			port := p.toPort(ctx, record, countryNames)
+			port.Offset = offset
+			select {
+			case portCh <- port:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return portCh, errCh
+}
+
+func (p *unlocodeParser) hasFunction(function string) bool {
+	if p.functions == "" {
+		return true
+	}
+	return strings.ContainsAny(strings.ReplaceAll(function, "-", ""), p.functions)
+}
+
+func (p *unlocodeParser) toPort(ctx context.Context, record []string, countryNames map[string]string) domain.Port {
+	country := strings.ToUpper(record[unlocodeCountry])
+	unloc := country + strings.ToUpper(record[unlocodeLocation])
+	name := record[unlocodeName]
+
+	port := domain.Port{
+		ID:      &unloc,
+		Name:    name,
+		City:    name,
+		Country: countryNames[country],
+		Alias:   []string{},
+		Regions: []string{},
+		Unlocs:  []string{unloc},
+	}
+	if withoutDiacritics := record[unlocodeNameWoDiacritics]; withoutDiacritics != "" && withoutDiacritics != name {
+		port.Alias = append(port.Alias, withoutDiacritics)
+	}
+	if c, ok := iso3166.CountryByCode(country); ok {
+		port.Country = c.Name
+	}
+
+	if subdivision := record[unlocodeSubdivision]; subdivision != "" {
+		port.Province = subdivision
+		if s, ok := iso3166.SubdivisionByCode(country + "-" + subdivision); ok {
+			port.Province = s.Name
+		}
+	}
+
+	if coordinates := record[unlocodeCoordinates]; coordinates != "" {
+		lon, lat, err := parseUNLOCODECoordinates(coordinates)
+		if err != nil {
+			// a few entries of the official lists have broken coordinates, the location is still worth importing
+			slog.WarnContext(ctx, "ignoring invalid unlocode coordinates", "unloc", unloc, "error", err)
+		} else {
+			port.Coordinates = []float64{lon, lat}
+		}
+	}
+
+	return port
+}
+
+// parseUNLOCODECoordinates converts the "DDMMN DDDMMW" notation of the code list to decimal degrees.
+func parseUNLOCODECoordinates(value string) (lon, lat float64, err error) {
+	parts := strings.Fields(value)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("coordinates %q are not in the DDMMN DDDMMW notation", value)
+	}
+
+	lat, err = parseDegreesMinutes(parts[0], 2, "N", "S", 90)
+	if err != nil {
+		return 0, 0, fmt.Errorf("latitude of %q: %w", value, err)
+	}
+	lon, err = parseDegreesMinutes(parts[1], 3, "E", "W", 180)
+	if err != nil {
+		return 0, 0, fmt.Errorf("longitude of %q: %w", value, err)
+	}
+
+	return lon, lat, nil
+}
+
+func parseDegreesMinutes(value string, degreeDigits int, positive, negative string, limit float64) (float64, error) {
+	if len(value) != degreeDigits+3 {
+		return 0, fmt.Errorf("%q should have %d digits and a hemisphere", value, degreeDigits+2)
+	}
+
+	degrees, err := strconv.Atoi(value[:degreeDigits])
+	if err != nil {
+		return 0, fmt.Errorf("%q has invalid degrees", value)
+	}
+	minutes, err := strconv.Atoi(value[degreeDigits : degreeDigits+2])
+	if err != nil || minutes >= 60 {
+		return 0, fmt.Errorf("%q has invalid minutes", value)
+	}
+
+	decimal := float64(degrees) + float64(minutes)/60
+	if decimal > limit {
+		return 0, fmt.Errorf("%q is out of range", value)
+	}
+
+	switch hemisphere := strings.ToUpper(value[degreeDigits+2:]); hemisphere {
+	case positive:
+		return decimal, nil
+	case negative:
+		return -decimal, nil
+	default:
+		return 0, fmt.Errorf("%q has an unknown hemisphere %q", value, hemisphere)
+	}
+}
+
+// toUTF8 decodes the ISO 8859-1 text of the older code list releases, newer ones are UTF-8.
+func toUTF8(value string) string {
+	if utf8.ValidString(value) {
+		return value
+	}
+
+	decoded, err := charmap.ISO8859_1.NewDecoder().String(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
@@ -0,0 +1,92 @@
+//go:build unit
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unlocodeData = `,"AE",,".UNITED ARAB EMIRATES",,,,,,,,
+,"AE","AJM","Ajman","Ajman","AJ","AI","1-------","9601",,"2525N 05527E",
+,"AE","AUH","Abu Dhabi","Abu Dhabi","AZ","AI","1234----","0507","AUH","2428N 05422E",
+,"AE","DXB","Dubai","Dubai","DU","AI","--3-----","9601",,"2515N 05518E",
+"X","AE","ZZZ","Deleted","Deleted",,"XX","1-------","2301",,,
+"=","AE",,"Dubayy = Dubai",,,,,,,,
+,"XK",,".KOSOVO",,,,,,,,
+,"XK","PRN","Prishtinë","Prishtine",,"RL","--3-----","1601",,"4240N 02110E",
+,"BR","SSZ","Santos","Santos","SP","AI","1-3-----","0001",,"2356S 04619W",
+`
+
+func TestUNLOCODEParser(t *testing.T) {
+	t.Run("code list should return seaports only", func(t *testing.T) {
+		ports, err := parseAll(t, NewUNLOCODEParser(strings.NewReader(unlocodeData), UNLOCODESeaport))
+		require.NoError(t, err)
+		require.Len(t, ports, 3)
+
+		assert.Equal(t, "AEAJM", *ports[0].ID)
+		assert.Equal(t, "Ajman", ports[0].Name)
+		assert.Equal(t, "United Arab Emirates", ports[0].Country)
+		assert.Equal(t, "‘Ajmān", ports[0].Province)
+		assert.Equal(t, []string{"AEAJM"}, ports[0].Unlocs)
+		assert.InDelta(t, 55.45, ports[0].Coordinates[0], 0.0001)
+		assert.InDelta(t, 25.4167, ports[0].Coordinates[1], 0.0001)
+
+		assert.Equal(t, "AEAUH", *ports[1].ID)
+		assert.Equal(t, "BRSSZ", *ports[2].ID)
+		assert.InDelta(t, -46.3167, ports[2].Coordinates[0], 0.0001)
+		assert.InDelta(t, -23.9333, ports[2].Coordinates[1], 0.0001)
+	})
+
+	t.Run("code list without function filter should return every location", func(t *testing.T) {
+		ports, err := parseAll(t, NewUNLOCODEParser(strings.NewReader(unlocodeData), ""))
+		require.NoError(t, err)
+		require.Len(t, ports, 5)
+
+		// XK is not in ISO 3166, the name of the country row is kept
+		assert.Equal(t, "XKPRN", *ports[3].ID)
+		assert.Equal(t, "Prishtinë", ports[3].Name)
+		assert.Equal(t, []string{"Prishtine"}, ports[3].Alias)
+		assert.Equal(t, "KOSOVO", ports[3].Country)
+	})
+
+	t.Run("latin-1 names should be decoded", func(t *testing.T) {
+		latin1 := ",\"DE\",\"MUN\",\"M\xfcnchen\",\"Munchen\",\"BY\",\"AI\",\"1-------\",\"9501\",,\"4808N 01135E\",\n"
+
+		ports, err := parseAll(t, NewUNLOCODEParser(strings.NewReader(latin1), ""))
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+		assert.Equal(t, "München", ports[0].Name)
+		assert.Equal(t, "Bayern", ports[0].Province)
+	})
+
+	t.Run("invalid coordinates should be left out", func(t *testing.T) {
+		data := `,"AE","AJM","Ajman","Ajman","AJ","AI","1-------","9601",,"2575N 05527E",`
+
+		ports, err := parseAll(t, NewUNLOCODEParser(strings.NewReader(data), ""))
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+		assert.Nil(t, ports[0].Coordinates)
+	})
+
+	t.Run("record with missing columns should return error", func(t *testing.T) {
+		_, err := parseAll(t, NewUNLOCODEParser(strings.NewReader(`,"AE","AJM","Ajman"`), ""))
+		assert.ErrorIs(t, err, domain.ErrInvalidCsv)
+	})
+}
+
+func TestParseUNLOCODECoordinates(t *testing.T) {
+	lon, lat, err := parseUNLOCODECoordinates("5155N 00410E")
+	require.NoError(t, err)
+	assert.InDelta(t, 4.1667, lon, 0.0001)
+	assert.InDelta(t, 51.9167, lat, 0.0001)
+
+	for _, invalid := range []string{"5155N", "5155X 00410E", "9130N 00410E", "5155N 18100W", "51N 00410E"} {
+		_, _, err := parseUNLOCODECoordinates(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
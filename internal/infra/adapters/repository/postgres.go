@@ -3,8 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/guil95/ports-service/internal/core/domain"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -18,80 +23,355 @@ func NewPostgresRepository(db *sqlx.DB) domain.RepositoryPort {
 	return &postgresRepository{db}
 }
 
-func (r *postgresRepository) SaveBulk(ctx context.Context, ports []domain.Port) error {
+// SaveBulk creates, updates and restores the ports with a multi-row INSERT. A live port whose content
+// hash did not change is not written at all: no new version, no history entry.
+func (r *postgresRepository) SaveBulk(ctx context.Context, ports []domain.Port) (domain.SaveResult, error) {
 	type PortDB struct {
-		ID          *string     `db:"id"`
-		Name        string      `db:"name"`
-		City        string      `db:"city"`
-		Country     string      `db:"country"`
-		Alias       interface{} `db:"alias"`
-		Regions     interface{} `db:"regions"`
-		Coordinates interface{} `db:"coordinates"`
-		Province    string      `db:"province"`
-		Timezone    string      `db:"timezone"`
-		Unlocs      interface{} `db:"unlocs"`
-		Code        string      `db:"code"`
+		ID              *string     `db:"id"`
+		Name            string      `db:"name"`
+		City            string      `db:"city"`
+		Country         string      `db:"country"`
+		CountryCode     string      `db:"country_code"`
+		Alias           interface{} `db:"alias"`
+		Regions         interface{} `db:"regions"`
+		Coordinates     interface{} `db:"coordinates"`
+		Province        string      `db:"province"`
+		SubdivisionCode string      `db:"subdivision_code"`
+		Timezone        string      `db:"timezone"`
+		Unlocs          interface{} `db:"unlocs"`
+		Code            string      `db:"code"`
+		ContentHash     string      `db:"content_hash"`
 	}
 
 	var portsDB []PortDB
 	for _, p := range ports {
 		portsDB = append(portsDB, PortDB{
-			ID:          p.ID,
-			Name:        p.Name,
-			City:        p.City,
-			Country:     p.Country,
-			Alias:       pq.Array(p.Alias),
-			Regions:     pq.Array(p.Regions),
-			Coordinates: pq.Array(p.Coordinates),
-			Province:    p.Province,
-			Timezone:    p.Timezone,
-			Unlocs:      pq.Array(p.Unlocs),
-			Code:        p.Code,
+			ID:              p.ID,
+			Name:            p.Name,
+			City:            p.City,
+			Country:         p.Country,
+			CountryCode:     p.CountryCode,
+			Alias:           pq.Array(p.Alias),
+			Regions:         pq.Array(p.Regions),
+			Coordinates:     pq.Array(p.Coordinates),
+			Province:        p.Province,
+			SubdivisionCode: p.SubdivisionCode,
+			Timezone:        p.Timezone,
+			Unlocs:          pq.Array(p.Unlocs),
+			Code:            p.Code,
+			ContentHash:     p.ContentHash(),
 		})
 	}
 
 	query := `
-		INSERT INTO ports (id, name, city, country, alias, regions, coordinates, province, timezone, unlocs, code)
-		VALUES (:id, :name, :city, :country, :alias, :regions, :coordinates, :province, :timezone, :unlocs, :code)
-		ON CONFLICT (id) DO UPDATE SET
-			name = EXCLUDED.name,
-			city = EXCLUDED.city,
-			country = EXCLUDED.country,
-			alias = EXCLUDED.alias,
-			regions = EXCLUDED.regions,
-			coordinates = EXCLUDED.coordinates,
-			province = EXCLUDED.province,
-			timezone = EXCLUDED.timezone,
-			unlocs = EXCLUDED.unlocs,
-			code = EXCLUDED.code;
+	WITH saved AS (
+		INSERT INTO ports (` + upsertColumns + `)
+		VALUES (:id, :name, :city, :country, NULLIF(:country_code, ''), :alias, :regions, :coordinates, :province, NULLIF(:subdivision_code, ''), :timezone, :unlocs, :code, :content_hash)
+		` + upsertConflict + `
+	)
+	` + countSaved
+
+	var result domain.SaveResult
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		bound, args, err := tx.BindNamed(query, portsDB)
+		if err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &result, bound, args...); err != nil {
+			return err
+		}
+		return tx.GetContext(ctx, &result.Skipped, countDeleted, pq.Array(portIDs(ports)))
+	})
+	if err != nil {
+		return domain.SaveResult{}, err
+	}
+
+	result.Unchanged = int64(len(ports)) - result.Created - result.Updated - result.Skipped
+	return result, nil
+}
+
+// upsertColumns are the columns SaveBulk and CopyBulk write, in the order of the COPY rows.
+const upsertColumns = `id, name, city, country, country_code, alias, regions, coordinates, province, subdivision_code, timezone, unlocs, code, content_hash`
+
+// MaxSaveBulkPorts is the largest batch SaveBulk takes: Postgres binds at most 65535 parameters to a
+// statement and a port binds one per upsert column.
+var MaxSaveBulkPorts = 65535 / len(strings.Split(upsertColumns, ","))
+
+// upsertConflict updates the live ports that already exist, unless their content hash is the same. The
+// deleted ports are left deleted: only Restore brings them back. It returns the written ports for countSaved.
+const upsertConflict = `
+	ON CONFLICT (id) DO UPDATE SET
+		name = EXCLUDED.name,
+		city = EXCLUDED.city,
+		country = EXCLUDED.country,
+		country_code = EXCLUDED.country_code,
+		alias = EXCLUDED.alias,
+		regions = EXCLUDED.regions,
+		coordinates = EXCLUDED.coordinates,
+		province = EXCLUDED.province,
+		subdivision_code = EXCLUDED.subdivision_code,
+		timezone = EXCLUDED.timezone,
+		unlocs = EXCLUDED.unlocs,
+		code = EXCLUDED.code,
+		content_hash = EXCLUDED.content_hash
+	WHERE ports.deleted_at IS NULL AND ports.content_hash IS DISTINCT FROM EXCLUDED.content_hash
+	RETURNING id, xmax = 0 AS inserted`
+
+// countSaved counts the ports written by the upsert of the "saved" query.
+const countSaved = `
+	SELECT
+		count(*) FILTER (WHERE saved.inserted) AS created,
+		count(*) FILTER (WHERE NOT saved.inserted) AS updated
+	FROM saved`
+
+// countDeleted counts the deleted ports among the IDs of a bulk save, the ones it left alone.
+const countDeleted = `SELECT count(*) FROM ports WHERE id = ANY($1) AND deleted_at IS NOT NULL`
+
+func portIDs(ports []domain.Port) []string {
+	ids := make([]string, 0, len(ports))
+	for _, p := range ports {
+		if p.ID != nil {
+			ids = append(ids, *p.ID)
+		}
+	}
+	return ids
+}
+
+// CopyBulk saves the ports like SaveBulk, but streams them with COPY into a staging table and merges
+// them into ports with a single INSERT ... SELECT. COPY has no limit on the number of ports, the multi-row
+// INSERT of SaveBulk has one of 65535 parameters (MaxSaveBulkPorts ports).
+func (r *postgresRepository) CopyBulk(ctx context.Context, ports []domain.Port) (domain.SaveResult, error) {
+	var result domain.SaveResult
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		// the staging table has the column types of ports and lives until the end of the transaction
+		_, err := tx.ExecContext(ctx, `
+		CREATE TEMPORARY TABLE ports_staging ON COMMIT DROP AS
+		SELECT `+upsertColumns+` FROM ports WITH NO DATA
+		`)
+		if err != nil {
+			return fmt.Errorf("error creating staging table: %v", err)
+		}
+
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn("ports_staging", strings.Split(upsertColumns, ", ")...))
+		if err != nil {
+			return fmt.Errorf("error starting copy: %v", err)
+		}
+		defer stmt.Close()
+
+		for _, p := range ports {
+			_, err := stmt.ExecContext(ctx, p.ID, p.Name, p.City, p.Country, p.CountryCode,
+				pq.Array(p.Alias), pq.Array(p.Regions), pq.Array(p.Coordinates),
+				p.Province, p.SubdivisionCode, p.Timezone, pq.Array(p.Unlocs), p.Code, p.ContentHash())
+			if err != nil {
+				return fmt.Errorf("error copying port: %v", err)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return fmt.Errorf("error copying ports: %v", err)
+		}
+
+		err = tx.GetContext(ctx, &result, `
+		WITH saved AS (
+			INSERT INTO ports (`+upsertColumns+`)
+			SELECT id, name, city, country, NULLIF(country_code, ''), alias, regions, coordinates, province,
+				NULLIF(subdivision_code, ''), timezone, unlocs, code, content_hash
+			FROM ports_staging
+			`+upsertConflict+`
+		)
+		`+countSaved)
+		if err != nil {
+			return fmt.Errorf("error merging staged ports: %v", err)
+		}
+		if err := tx.GetContext(ctx, &result.Skipped, countDeleted, pq.Array(portIDs(ports))); err != nil {
+			return fmt.Errorf("error counting deleted ports: %v", err)
+		}
+
+		// dropped now rather than at commit, InTransaction copies every batch in the same transaction
+		_, err = tx.ExecContext(ctx, `DROP TABLE ports_staging`)
+		return err
+	})
+	if err != nil {
+		return domain.SaveResult{}, err
+	}
+
+	result.Unchanged = int64(len(ports)) - result.Created - result.Updated - result.Skipped
+	return result, nil
+}
+
+// SaveIfVersion updates the port only if the stored version is still the given one (compare-and-swap)
+// and returns the new version. Unlike SaveBulk it never creates nor restores a port.
+func (r *postgresRepository) SaveIfVersion(ctx context.Context, port domain.Port, version int64) (int64, error) {
+	query := `
+	UPDATE ports SET
+		name = $3,
+		city = $4,
+		country = $5,
+		alias = $6,
+		regions = $7,
+		coordinates = $8,
+		province = $9,
+		timezone = $10,
+		unlocs = $11,
+		code = $12,
+		country_code = NULLIF($13, ''),
+		subdivision_code = NULLIF($14, ''),
+		content_hash = $15
+	WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+	RETURNING version
 	`
 
-	_, err := r.db.NamedExecContext(ctx, query, portsDB)
+	var newVersion int64
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &newVersion, query, port.ID, version,
+			port.Name, port.City, port.Country, pq.Array(port.Alias), pq.Array(port.Regions), pq.Array(port.Coordinates),
+			port.Province, port.Timezone, pq.Array(port.Unlocs), port.Code, port.CountryCode, port.SubdivisionCode,
+			port.ContentHash())
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error updating port: %v", err)
+		}
+
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM ports WHERE id = $1 AND deleted_at IS NULL)`, port.ID); err != nil {
+			return fmt.Errorf("error checking port: %v", err)
+		}
+		if !exists {
+			return domain.ErrPortNotFound
+		}
+		return domain.ErrVersionConflict
+	})
+
+	return newVersion, err
+}
+
+// txKey is the context key of the transaction of InTransaction.
+type txKey struct{}
+
+// InTransaction runs fn in a single transaction: the repository calls made with the context fn receives
+// take part in it, and their changes are committed together when fn returns nil, rolled back otherwise.
+// The transaction is not safe for concurrent use, fn must not make concurrent repository calls.
+func (r *postgresRepository) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// querier is the transaction of InTransaction when the context has one, the database otherwise.
+func (r *postgresRepository) querier(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// inTx runs fn in a transaction tagged with the change source of the context,
+// so the history trigger knows who wrote the rows. Inside InTransaction fn runs in a savepoint
+// instead: a failed statement would abort the whole transaction, the savepoint only undoes fn.
+func (r *postgresRepository) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return inSavepoint(ctx, tx, fn)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the actor comes from clients (X-Actor) and file names, it is cut to the size of port_history.actor
+	source := domain.ChangeSourceFromContext(ctx)
+	_, err = tx.ExecContext(ctx, `
+	SELECT set_config('ports.change_source', $1, true),
+		set_config('ports.change_actor', left($2, 255), true),
+		set_config('ports.change_run_id', $3, true)
+	`, source.Kind, source.Actor, source.RunID)
+	if err != nil {
+		return fmt.Errorf("error setting change source: %v", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func inSavepoint(ctx context.Context, tx *sqlx.Tx, fn func(tx *sqlx.Tx) error) error {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT repository`); err != nil {
+		return fmt.Errorf("error creating savepoint: %v", err)
+	}
+	if err := fn(tx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT repository`); rollbackErr != nil {
+			return fmt.Errorf("error rolling back to savepoint: %v; error: %w", rollbackErr, err)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT repository`)
 	return err
 }
 
 func (r *postgresRepository) FindByID(ctx context.Context, id string) (*domain.Port, error) {
 	query := `
-	SELECT id, name, city, country, alias, regions, coordinates, province, timezone, unlocs, code
+	SELECT ` + portColumns + `
 	FROM ports
-	WHERE LOWER(id) = LOWER($1)
+	WHERE LOWER(id) = LOWER($1) AND deleted_at IS NULL
 	`
 
-	var rawPort struct {
-		ID          string          `db:"id"`
-		Name        string          `db:"name"`
-		City        string          `db:"city"`
-		Country     string          `db:"country"`
-		Alias       pq.StringArray  `db:"alias"`
-		Regions     pq.StringArray  `db:"regions"`
-		Coordinates pq.Float64Array `db:"coordinates"`
-		Province    string          `db:"province"`
-		Timezone    string          `db:"timezone"`
-		Unlocs      pq.StringArray  `db:"unlocs"`
-		Code        string          `db:"code"`
+	var rawPort portRow
+	err := sqlx.GetContext(ctx, r.querier(ctx), &rawPort, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrPortNotFound
+		}
+		return nil, fmt.Errorf("error fetching port: %v", err)
+	}
+
+	return rawPort.toDomain(), nil
+}
+
+// FindByIDs returns the stored ports among ids, keyed by ID. IDs match exactly, like SaveBulk upserts.
+func (r *postgresRepository) FindByIDs(ctx context.Context, ids []string) (map[string]domain.Port, error) {
+	query := `
+	SELECT ` + portColumns + `
+	FROM ports
+	WHERE id = ANY($1) AND deleted_at IS NULL
+	`
+
+	var rows []portRow
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("error fetching ports by ids: %v", err)
+	}
+
+	ports := make(map[string]domain.Port, len(rows))
+	for _, row := range rows {
+		ports[row.ID] = *row.toDomain()
 	}
 
-	err := r.db.GetContext(ctx, &rawPort, query, id)
+	return ports, nil
+}
+
+// FindByIdentifier matches the ID first, then any of the UN/LOCODEs and then the aliases, case-insensitively.
+// A UN/LOCODE listed by several ports resolves to the port where it comes first, an alias shared by several
+// ports to the first ID.
+func (r *postgresRepository) FindByIdentifier(ctx context.Context, identifier string) (*domain.Port, error) {
+	query := `
+	SELECT ` + portColumns + `
+	FROM ports
+	WHERE (LOWER(id) = LOWER($1) OR unlocs @> ARRAY[UPPER($1)]::TEXT[] OR lower_array(alias) @> ARRAY[LOWER($1)]::TEXT[])
+		AND deleted_at IS NULL
+	ORDER BY LOWER(id) = LOWER($1) DESC, array_position(unlocs, UPPER($1)), id
+	LIMIT 1
+	`
+
+	var rawPort portRow
+	err := sqlx.GetContext(ctx, r.querier(ctx), &rawPort, query, identifier)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrPortNotFound
@@ -99,19 +379,818 @@ func (r *postgresRepository) FindByID(ctx context.Context, id string) (*domain.P
 		return nil, fmt.Errorf("error fetching port: %v", err)
 	}
 
-	port := &domain.Port{
-		ID:          &rawPort.ID,
-		Name:        rawPort.Name,
-		City:        rawPort.City,
-		Country:     rawPort.Country,
-		Alias:       []string(rawPort.Alias),
-		Regions:     []string(rawPort.Regions),
-		Coordinates: []float64(rawPort.Coordinates),
-		Province:    rawPort.Province,
-		Timezone:    rawPort.Timezone,
-		Unlocs:      []string(rawPort.Unlocs),
-		Code:        rawPort.Code,
+	return rawPort.toDomain(), nil
+}
+
+func (r *postgresRepository) FindByCode(ctx context.Context, code string) ([]domain.Port, error) {
+	query := `
+	SELECT ` + portColumns + `
+	FROM ports
+	WHERE code = $1 AND deleted_at IS NULL
+	ORDER BY id
+	`
+
+	var rows []portRow
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, query, code); err != nil {
+		return nil, fmt.Errorf("error fetching ports by code: %v", err)
+	}
+
+	ports := make([]domain.Port, 0, len(rows))
+	for _, row := range rows {
+		ports = append(ports, *row.toDomain())
+	}
+
+	return ports, nil
+}
+
+func (r *postgresRepository) FindNearby(ctx context.Context, query domain.NearbyQuery) ([]domain.NearbyPort, error) {
+	// coordinates are stored as [lon, lat]; distance uses the haversine formula over the mean earth radius
+	sqlQuery := `
+	SELECT ` + portColumns + `, distance_km
+	FROM (
+		SELECT *,
+			2 * 6371.0088 * ASIN(LEAST(1, SQRT(
+				POWER(SIN(RADIANS(coordinates[2] - $1) / 2), 2) +
+				COS(RADIANS($1)) * COS(RADIANS(coordinates[2])) *
+				POWER(SIN(RADIANS(coordinates[1] - $2) / 2), 2)
+			))) AS distance_km
+		FROM ports
+		WHERE array_length(coordinates, 1) = 2 AND deleted_at IS NULL
+	) AS nearby
+	WHERE distance_km <= $3
+	ORDER BY distance_km, id
+	LIMIT $4
+	`
+
+	var rows []struct {
+		portRow
+		DistanceKm float64 `db:"distance_km"`
+	}
+	err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, sqlQuery, query.Latitude, query.Longitude, query.RadiusKm, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching nearby ports: %v", err)
+	}
+
+	ports := make([]domain.NearbyPort, 0, len(rows))
+	for _, row := range rows {
+		ports = append(ports, domain.NearbyPort{Port: *row.toDomain(), DistanceKm: row.DistanceKm})
+	}
+
+	return ports, nil
+}
+
+func (r *postgresRepository) List(ctx context.Context, query domain.ListQuery) (*domain.PortPage, error) {
+	column, descending := strings.TrimPrefix(query.Sort, "-"), strings.HasPrefix(query.Sort, "-")
+	sortExpr := "id"
+	if column != "id" {
+		sortExpr = fmt.Sprintf("COALESCE(%s, '')", column)
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
 	}
 
-	return port, nil
+	if query.Country != "" {
+		addCondition("LOWER(country) = LOWER($%d)", query.Country)
+	}
+	if query.CountryCode != "" {
+		addCondition("country_code = UPPER($%d)", query.CountryCode)
+	}
+	if query.Province != "" {
+		addCondition("LOWER(province) = LOWER($%d)", query.Province)
+	}
+	if query.City != "" {
+		addCondition("LOWER(city) = LOWER($%d)", query.City)
+	}
+	if query.Timezone != "" {
+		addCondition("LOWER(timezone) = LOWER($%d)", query.Timezone)
+	}
+	if query.Code != "" {
+		addCondition("code = $%d", query.Code)
+	}
+	if query.Region != "" {
+		addCondition("regions @> ARRAY[$%d]::TEXT[]", query.Region)
+	}
+
+	comparison, direction := ">", "ASC"
+	if descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, c.Value, c.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", sortExpr, comparison, len(args)-1, len(args)))
+	}
+
+	// one extra row tells whether there is a next page
+	args = append(args, query.Limit+1)
+	sqlQuery := fmt.Sprintf(`
+	SELECT %s
+	FROM ports
+	WHERE %s
+	ORDER BY %s %s, id %s
+	LIMIT $%d
+	`, portColumns, strings.Join(conditions, " AND "), sortExpr, direction, direction, len(args))
+
+	var rows []portRow
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, sqlQuery, args...); err != nil {
+		return nil, fmt.Errorf("error listing ports: %v", err)
+	}
+
+	page := &domain.PortPage{Ports: make([]domain.Port, 0, len(rows))}
+	if len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(query.Sort, last.sortValue(column), last.ID)
+	}
+	for _, row := range rows {
+		page.Ports = append(page.Ports, *row.toDomain())
+	}
+
+	return page, nil
+}
+
+func (r *postgresRepository) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	// search_text and search_name are normalized by the database (see migrations/000003_add_ports_search.up.sql),
+	// the term goes through the same normalization so accents and odd encodings match on both sides
+	sqlQuery := `
+	SELECT ` + portColumns + `, word_similarity(q.term, search_text) AS score
+	FROM ports, (SELECT ports_search_normalize($1) AS term) AS q
+	WHERE q.term <% search_text AND deleted_at IS NULL
+	ORDER BY score DESC, similarity(q.term, search_name) DESC, id
+	LIMIT $2
+	`
+
+	var rows []struct {
+		portRow
+		Score float64 `db:"score"`
+	}
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, sqlQuery, query.Term, query.Limit); err != nil {
+		return nil, fmt.Errorf("error searching ports: %v", err)
+	}
+
+	results := make([]domain.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, domain.SearchResult{Port: *row.toDomain(), Score: row.Score})
+	}
+
+	return results, nil
+}
+
+// CountByCountry counts the ports of each ISO 3166-1 country, ports without a country code are left out.
+func (r *postgresRepository) CountByCountry(ctx context.Context) (map[string]int64, error) {
+	query := `
+	SELECT country_code, COUNT(*) AS ports
+	FROM ports
+	WHERE country_code IS NOT NULL AND deleted_at IS NULL
+	GROUP BY country_code
+	`
+
+	var rows []struct {
+		CountryCode string `db:"country_code"`
+		Ports       int64  `db:"ports"`
+	}
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, query); err != nil {
+		return nil, fmt.Errorf("error counting ports by country: %v", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CountryCode] = row.Ports
+	}
+
+	return counts, nil
+}
+
+func (r *postgresRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := sqlx.GetContext(ctx, r.querier(ctx), &count, `SELECT COUNT(*) FROM ports WHERE deleted_at IS NULL`); err != nil {
+		return 0, fmt.Errorf("error counting ports: %v", err)
+	}
+
+	return count, nil
+}
+
+// MissingIDs returns the IDs of the ports not listed in ids, sorted.
+func (r *postgresRepository) MissingIDs(ctx context.Context, ids []string) ([]string, error) {
+	query := `
+	SELECT id
+	FROM ports
+	WHERE NOT (id = ANY($1)) AND deleted_at IS NULL
+	ORDER BY id
+	`
+
+	missing := []string{}
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &missing, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("error fetching missing ports: %v", err)
+	}
+
+	return missing, nil
+}
+
+// DeletedIDs returns the IDs among ids whose port is deleted, that a bulk save leaves alone.
+func (r *postgresRepository) DeletedIDs(ctx context.Context, ids []string) ([]string, error) {
+	query := `
+	SELECT id
+	FROM ports
+	WHERE id = ANY($1) AND deleted_at IS NOT NULL
+	ORDER BY id
+	`
+
+	deleted := []string{}
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &deleted, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("error fetching deleted ports: %v", err)
+	}
+
+	return deleted, nil
+}
+
+func (r *postgresRepository) Delete(ctx context.Context, id string) error {
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		return expectOneRow(tx.ExecContext(ctx, `
+		UPDATE ports SET deleted_at = now()
+		WHERE LOWER(id) = LOWER($1) AND deleted_at IS NULL
+		`, id))
+	})
+}
+
+// DeleteIfVersion soft-deletes the port only if the stored version is still the given one.
+func (r *postgresRepository) DeleteIfVersion(ctx context.Context, id string, version int64) error {
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+		UPDATE ports SET deleted_at = now()
+		WHERE LOWER(id) = LOWER($1) AND deleted_at IS NULL AND version = $2
+		`, id, version)
+		return expectVersion(ctx, tx, id, false, result, err)
+	})
+}
+
+// DeleteMany soft-deletes the ports with the given IDs, matched exactly, and returns how many it deleted.
+func (r *postgresRepository) DeleteMany(ctx context.Context, ids []string) (int64, error) {
+	var deleted int64
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+		UPDATE ports SET deleted_at = now()
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		`, pq.Array(ids))
+		if err != nil {
+			return fmt.Errorf("error deleting ports: %v", err)
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *postgresRepository) Restore(ctx context.Context, id string) error {
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		return expectOneRow(tx.ExecContext(ctx, `
+		UPDATE ports SET deleted_at = NULL
+		WHERE LOWER(id) = LOWER($1) AND deleted_at IS NOT NULL
+		`, id))
+	})
+}
+
+// RestoreIfVersion restores the deleted port only if the stored version is still the given one.
+func (r *postgresRepository) RestoreIfVersion(ctx context.Context, id string, version int64) error {
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+		UPDATE ports SET deleted_at = NULL
+		WHERE LOWER(id) = LOWER($1) AND deleted_at IS NOT NULL AND version = $2
+		`, id, version)
+		return expectVersion(ctx, tx, id, true, result, err)
+	})
+}
+
+func (r *postgresRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM ports WHERE deleted_at < $1`, deletedBefore)
+		if err != nil {
+			return fmt.Errorf("error purging ports: %v", err)
+		}
+		purged, err = result.RowsAffected()
+		return err
+	})
+
+	return purged, err
+}
+
+func expectOneRow(result sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("error updating port: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrPortNotFound
+	}
+
+	return nil
+}
+
+// expectVersion is expectOneRow for the writes that require a version: when no row was written, it tells
+// a port that does not exist (or is not deleted, with deleted) from one whose version changed.
+func expectVersion(ctx context.Context, tx *sqlx.Tx, id string, deleted bool, result sql.Result, err error) error {
+	err = expectOneRow(result, err)
+	if !errors.Is(err, domain.ErrPortNotFound) {
+		return err
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ports WHERE LOWER(id) = LOWER($1) AND (deleted_at IS NOT NULL) = $2)`
+	if err := tx.GetContext(ctx, &exists, query, id, deleted); err != nil {
+		return fmt.Errorf("error checking port: %v", err)
+	}
+	if !exists {
+		return domain.ErrPortNotFound
+	}
+	return domain.ErrVersionConflict
+}
+
+func (r *postgresRepository) SaveCheckpoint(ctx context.Context, checkpoint domain.Checkpoint) error {
+	query := `
+	INSERT INTO import_checkpoints (fingerprint, source, last_key, byte_offset, imported, updated_at)
+	VALUES (:fingerprint, :source, :last_key, :byte_offset, :imported, now())
+	ON CONFLICT (fingerprint) DO UPDATE SET
+		source = EXCLUDED.source,
+		last_key = EXCLUDED.last_key,
+		byte_offset = EXCLUDED.byte_offset,
+		imported = EXCLUDED.imported,
+		updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := sqlx.NamedExecContext(ctx, r.querier(ctx), query, checkpoint); err != nil {
+		return fmt.Errorf("error saving checkpoint: %v", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) FindCheckpoint(ctx context.Context, fingerprint string) (*domain.Checkpoint, error) {
+	query := `
+	SELECT fingerprint, source, last_key, byte_offset, imported, updated_at
+	FROM import_checkpoints
+	WHERE fingerprint = $1
+	`
+
+	var checkpoint domain.Checkpoint
+	if err := sqlx.GetContext(ctx, r.querier(ctx), &checkpoint, query, fingerprint); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrCheckpointNotFound
+		}
+		return nil, fmt.Errorf("error fetching checkpoint: %v", err)
+	}
+
+	return &checkpoint, nil
+}
+
+func (r *postgresRepository) DeleteCheckpoint(ctx context.Context, fingerprint string) error {
+	if _, err := r.querier(ctx).ExecContext(ctx, `DELETE FROM import_checkpoints WHERE fingerprint = $1`, fingerprint); err != nil {
+		return fmt.Errorf("error deleting checkpoint: %v", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) CreateImportJob(ctx context.Context, job domain.ImportJob) error {
+	query := `
+	INSERT INTO import_jobs (id, status, format, source)
+	VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := r.querier(ctx).ExecContext(ctx, query, job.ID, job.Status, job.Format, job.Source); err != nil {
+		return fmt.Errorf("error creating import job: %v", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) SaveImportJobProgress(ctx context.Context, id string, summary domain.ImportSummary) (bool, error) {
+	query := `
+	UPDATE import_jobs SET imported = $2, rejected = $3, skipped = $4, deleted = $5, created = $6, updated = $7,
+		unchanged = $8, updated_at = now()
+	WHERE id = $1
+	RETURNING cancel_requested
+	`
+
+	var cancelRequested bool
+	err := sqlx.GetContext(ctx, r.querier(ctx), &cancelRequested, query, id, summary.Imported, summary.Rejected,
+		summary.Skipped, summary.Deleted, summary.Created, summary.Updated, summary.Unchanged)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, domain.ErrImportJobNotFound
+		}
+		return false, fmt.Errorf("error saving import job progress: %v", err)
+	}
+
+	return cancelRequested, nil
+}
+
+func (r *postgresRepository) FinishImportJob(ctx context.Context, job domain.ImportJob) error {
+	query := `
+	UPDATE import_jobs SET status = $2, imported = $3, rejected = $4, skipped = $5, deleted = $6, error = $7,
+		created = $8, updated = $9, unchanged = $10, updated_at = now(), finished_at = now()
+	WHERE id = $1
+	`
+
+	result, err := r.querier(ctx).ExecContext(ctx, query, job.ID, job.Status,
+		job.Summary.Imported, job.Summary.Rejected, job.Summary.Skipped, job.Summary.Deleted, job.Error,
+		job.Summary.Created, job.Summary.Updated, job.Summary.Unchanged)
+	if err != nil {
+		return fmt.Errorf("error finishing import job: %v", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		if err != nil {
+			return err
+		}
+		return domain.ErrImportJobNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) FindImportJob(ctx context.Context, id string) (*domain.ImportJob, error) {
+	query := `
+	SELECT id, status, format, source, imported, rejected, skipped, deleted, created, updated, unchanged, error,
+		cancel_requested, created_at, updated_at, finished_at
+	FROM import_jobs
+	WHERE id = $1
+	`
+
+	var row importJobRow
+	if err := sqlx.GetContext(ctx, r.querier(ctx), &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("error fetching import job: %v", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+// RequestImportJobCancel flags a running job, the server running it stops it at its next progress update.
+func (r *postgresRepository) RequestImportJobCancel(ctx context.Context, id string) error {
+	result, err := r.querier(ctx).ExecContext(ctx, `
+	UPDATE import_jobs SET cancel_requested = true, updated_at = now()
+	WHERE id = $1 AND status = $2
+	`, id, domain.ImportJobRunning)
+	if err != nil {
+		return fmt.Errorf("error canceling import job: %v", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+
+	// the job is either unknown or finished
+	if _, err := r.FindImportJob(ctx, id); err != nil {
+		return err
+	}
+	return domain.ErrImportJobFinished
+}
+
+func (r *postgresRepository) FinishStaleImportJobs(ctx context.Context, staleSince time.Time, reason string) (int64, error) {
+	query := `
+	WITH stale AS (
+		UPDATE import_jobs SET status = CASE WHEN cancel_requested THEN $3 ELSE $4 END, error = $2,
+			updated_at = now(), finished_at = now()
+		WHERE status = $5 AND updated_at < $1
+		RETURNING id, status, imported, rejected, skipped, deleted, created, updated, unchanged
+	), runs AS (
+		UPDATE import_runs AS run SET outcome = stale.status, error = $2, written = stale.imported,
+			rejected = stale.rejected, skipped = stale.skipped, deleted = stale.deleted, created = stale.created,
+			updated = stale.updated, unchanged = stale.unchanged, finished_at = now()
+		FROM stale
+		WHERE run.id = stale.id AND run.outcome = $5
+	)
+	SELECT count(*) FROM stale
+	`
+
+	var finished int64
+	err := sqlx.GetContext(ctx, r.querier(ctx), &finished, query, staleSince, reason,
+		domain.ImportJobCanceled, domain.ImportJobFailed, domain.ImportJobRunning)
+	if err != nil {
+		return 0, fmt.Errorf("error finishing stale import jobs: %v", err)
+	}
+
+	return finished, nil
+}
+
+func (r *postgresRepository) CreateImportRun(ctx context.Context, run domain.ImportRun) error {
+	query := `
+	INSERT INTO import_runs (id, source, format, mode, dry_run, size, checksum, outcome, started_at)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6::BIGINT, 0), NULLIF($7, ''), $8, $9)
+	`
+
+	_, err := r.querier(ctx).ExecContext(ctx, query, run.ID, run.Source, run.Format, run.Mode, run.DryRun,
+		run.Size, run.Checksum, run.Outcome, run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("error creating import run: %v", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) FinishImportRun(ctx context.Context, run domain.ImportRun) error {
+	query := `
+	UPDATE import_runs SET outcome = $2, error = $3, parsed = $4, written = $5, rejected = $6, skipped = $7,
+		deleted = $8, created = $9, updated = $10, unchanged = $11, finished_at = $12,
+		size = COALESCE(size, NULLIF($13::BIGINT, 0)), checksum = COALESCE(checksum, NULLIF($14, ''))
+	WHERE id = $1
+	`
+
+	// the standard input is only measured once it is read
+	result, err := r.querier(ctx).ExecContext(ctx, query, run.ID, run.Outcome, run.Error, run.Summary.Parsed,
+		run.Summary.Imported, run.Summary.Rejected, run.Summary.Skipped, run.Summary.Deleted,
+		run.Summary.Created, run.Summary.Updated, run.Summary.Unchanged, run.FinishedAt, run.Size, run.Checksum)
+	if err != nil {
+		return fmt.Errorf("error finishing import run: %v", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		if err != nil {
+			return err
+		}
+		return domain.ErrImportRunNotFound
+	}
+
+	return nil
+}
+
+// importRunColumns are the columns of importRunRow.
+const importRunColumns = `id, source, format, mode, dry_run, COALESCE(size, 0) AS size, COALESCE(checksum, '') AS checksum,
+	outcome, error, parsed, written, rejected, skipped, deleted, created, updated, unchanged, started_at, finished_at`
+
+func (r *postgresRepository) FindLastImportRun(ctx context.Context) (*domain.ImportRun, error) {
+	query := `
+	SELECT ` + importRunColumns + `
+	FROM import_runs
+	WHERE NOT dry_run AND outcome <> $1
+	ORDER BY started_at DESC
+	LIMIT 1
+	`
+
+	var row importRunRow
+	if err := sqlx.GetContext(ctx, r.querier(ctx), &row, query, domain.ImportUnchanged); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrImportRunNotFound
+		}
+		return nil, fmt.Errorf("error fetching last import run: %v", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+func (r *postgresRepository) ListImportRuns(ctx context.Context, limit int) ([]domain.ImportRun, error) {
+	query := `
+	SELECT ` + importRunColumns + `
+	FROM import_runs
+	ORDER BY started_at DESC
+	LIMIT $1
+	`
+
+	var rows []importRunRow
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, query, limit); err != nil {
+		return nil, fmt.Errorf("error listing import runs: %v", err)
+	}
+
+	runs := make([]domain.ImportRun, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, *row.toDomain())
+	}
+
+	return runs, nil
+}
+
+// History lists the changes of the port with exactly the given ID, newest first, along the
+// idx_port_history_port_id index. The ID is resolved by the service.
+func (r *postgresRepository) History(ctx context.Context, portID string) ([]domain.PortChange, error) {
+	query := `
+	SELECT id, port_id, operation, changed_at, source, COALESCE(actor, '') AS actor, COALESCE(run_id, '') AS run_id, previous, diff
+	FROM port_history
+	WHERE port_id = $1
+	ORDER BY changed_at DESC, id DESC
+	`
+
+	var rows []struct {
+		ID        int64     `db:"id"`
+		PortID    string    `db:"port_id"`
+		Operation string    `db:"operation"`
+		ChangedAt time.Time `db:"changed_at"`
+		Source    string    `db:"source"`
+		Actor     string    `db:"actor"`
+		RunID     string    `db:"run_id"`
+		Previous  []byte    `db:"previous"`
+		Diff      []byte    `db:"diff"`
+	}
+	if err := sqlx.SelectContext(ctx, r.querier(ctx), &rows, query, portID); err != nil {
+		return nil, fmt.Errorf("error fetching port history: %v", err)
+	}
+
+	changes := make([]domain.PortChange, 0, len(rows))
+	for _, row := range rows {
+		change := domain.PortChange{
+			ID:        row.ID,
+			PortID:    row.PortID,
+			Operation: row.Operation,
+			ChangedAt: row.ChangedAt,
+			Source:    row.Source,
+			Actor:     row.Actor,
+			RunID:     row.RunID,
+		}
+		if row.Previous != nil {
+			if err := json.Unmarshal(row.Previous, &change.Previous); err != nil {
+				return nil, fmt.Errorf("error decoding previous version: %v", err)
+			}
+		}
+		if err := json.Unmarshal(row.Diff, &change.Diff); err != nil {
+			return nil, fmt.Errorf("error decoding diff: %v", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// cursor is the keyset position of the last port of a page, bound to the sort it was created with.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(sort, value, id string) string {
+	raw, _ := json.Marshal(cursor{Sort: sort, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded, sort string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was created for another sort", domain.ErrInvalidQuery)
+	}
+
+	return &c, nil
+}
+
+const portColumns = "id, name, city, country, COALESCE(country_code, '') AS country_code, alias, regions, coordinates, " +
+	"province, COALESCE(subdivision_code, '') AS subdivision_code, timezone, unlocs, code, version"
+
+type importJobRow struct {
+	ID              string     `db:"id"`
+	Status          string     `db:"status"`
+	Format          string     `db:"format"`
+	Source          string     `db:"source"`
+	Imported        int64      `db:"imported"`
+	Rejected        int64      `db:"rejected"`
+	Skipped         int64      `db:"skipped"`
+	Deleted         int64      `db:"deleted"`
+	Created         int64      `db:"created"`
+	Updated         int64      `db:"updated"`
+	Unchanged       int64      `db:"unchanged"`
+	Error           string     `db:"error"`
+	CancelRequested bool       `db:"cancel_requested"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	FinishedAt      *time.Time `db:"finished_at"`
+}
+
+func (row importJobRow) toDomain() *domain.ImportJob {
+	return &domain.ImportJob{
+		ID:     row.ID,
+		Status: domain.ImportJobStatus(row.Status),
+		Format: row.Format,
+		Source: row.Source,
+		Summary: domain.ImportSummary{
+			Imported:  row.Imported,
+			Rejected:  row.Rejected,
+			Skipped:   row.Skipped,
+			Deleted:   row.Deleted,
+			Created:   row.Created,
+			Updated:   row.Updated,
+			Unchanged: row.Unchanged,
+		},
+		Error:           row.Error,
+		CancelRequested: row.CancelRequested,
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+		FinishedAt:      row.FinishedAt,
+	}
+}
+
+type importRunRow struct {
+	ID         string     `db:"id"`
+	Source     string     `db:"source"`
+	Format     string     `db:"format"`
+	Mode       string     `db:"mode"`
+	DryRun     bool       `db:"dry_run"`
+	Size       int64      `db:"size"`
+	Checksum   string     `db:"checksum"`
+	Outcome    string     `db:"outcome"`
+	Error      string     `db:"error"`
+	Parsed     int64      `db:"parsed"`
+	Written    int64      `db:"written"`
+	Rejected   int64      `db:"rejected"`
+	Skipped    int64      `db:"skipped"`
+	Deleted    int64      `db:"deleted"`
+	Created    int64      `db:"created"`
+	Updated    int64      `db:"updated"`
+	Unchanged  int64      `db:"unchanged"`
+	StartedAt  time.Time  `db:"started_at"`
+	FinishedAt *time.Time `db:"finished_at"`
+}
+
+func (row importRunRow) toDomain() *domain.ImportRun {
+	return &domain.ImportRun{
+		ID:       row.ID,
+		Source:   row.Source,
+		Format:   row.Format,
+		Mode:     row.Mode,
+		DryRun:   row.DryRun,
+		Size:     row.Size,
+		Checksum: row.Checksum,
+		Outcome:  domain.ImportOutcome(row.Outcome),
+		Error:    row.Error,
+		Summary: domain.ImportSummary{
+			Parsed:    row.Parsed,
+			Imported:  row.Written,
+			Rejected:  row.Rejected,
+			Skipped:   row.Skipped,
+			Deleted:   row.Deleted,
+			Created:   row.Created,
+			Updated:   row.Updated,
+			Unchanged: row.Unchanged,
+		},
+		StartedAt:  row.StartedAt,
+		FinishedAt: row.FinishedAt,
+	}
+}
+
+type portRow struct {
+	ID              string          `db:"id"`
+	Name            string          `db:"name"`
+	City            string          `db:"city"`
+	Country         string          `db:"country"`
+	CountryCode     string          `db:"country_code"`
+	Alias           pq.StringArray  `db:"alias"`
+	Regions         pq.StringArray  `db:"regions"`
+	Coordinates     pq.Float64Array `db:"coordinates"`
+	Province        string          `db:"province"`
+	SubdivisionCode string          `db:"subdivision_code"`
+	Timezone        string          `db:"timezone"`
+	Unlocs          pq.StringArray  `db:"unlocs"`
+	Code            string          `db:"code"`
+	Version         int64           `db:"version"`
+}
+
+func (row portRow) sortValue(column string) string {
+	switch column {
+	case "name":
+		return row.Name
+	case "city":
+		return row.City
+	case "country":
+		return row.Country
+	case "code":
+		return row.Code
+	default:
+		return row.ID
+	}
+}
+
+func (row portRow) toDomain() *domain.Port {
+	return &domain.Port{
+		ID:              &row.ID,
+		Name:            row.Name,
+		City:            row.City,
+		Country:         row.Country,
+		CountryCode:     row.CountryCode,
+		Alias:           []string(row.Alias),
+		Regions:         []string(row.Regions),
+		Coordinates:     []float64(row.Coordinates),
+		Province:        row.Province,
+		SubdivisionCode: row.SubdivisionCode,
+		Timezone:        row.Timezone,
+		Unlocs:          []string(row.Unlocs),
+		Code:            row.Code,
+		Version:         row.Version,
+	}
 }
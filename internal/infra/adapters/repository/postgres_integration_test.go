@@ -4,11 +4,16 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/guil95/ports-service/internal/core/domain"
 	"github.com/guil95/ports-service/tests/suite"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPostgresRepository(t *testing.T) {
@@ -49,9 +54,12 @@ func TestPostgresRepository(t *testing.T) {
 			},
 		}
 
-		err := repo.SaveBulk(context.Background(), ports)
+		_, err := repo.SaveBulk(context.Background(), ports)
 		assert.NoError(t, err)
 
+		// new ports start at version 1
+		ports[0].Version, ports[1].Version = 1, 1
+
 		retrievedPort, err := repo.FindByID(ctx, *ports[0].ID)
 		assert.NoError(t, err)
 		assert.Equal(t, ports[0], *retrievedPort)
@@ -67,6 +75,819 @@ func TestPostgresRepository(t *testing.T) {
 	})
 }
 
+func TestPostgresRepositoryFindNearby(t *testing.T) {
+	t.Run("find nearby ports ordered by distance", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEAJM"), Name: "Ajman", Coordinates: []float64{55.5136433, 25.4052165}, Unlocs: []string{"AEAJM"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Coordinates: []float64{55.27, 25.25}, Unlocs: []string{"AEDXB"}},
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Coordinates: []float64{54.37, 24.47}, Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("CNBJO"), Name: "Beijiao", Coordinates: []float64{119.92, 26.35}, Unlocs: []string{"CNBJO"}},
+			{ID: stringPtr("GBEIL"), Name: "No coordinates", Unlocs: []string{"GBEIL"}},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+
+		nearby, err := repo.FindNearby(ctx, domain.NearbyQuery{Latitude: 25.25, Longitude: 55.27, RadiusKm: 200, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, nearby, 3)
+		assert.Equal(t, "AEDXB", *nearby[0].ID)
+		assert.Equal(t, "AEAJM", *nearby[1].ID)
+		assert.Equal(t, "AEAUH", *nearby[2].ID)
+		assert.InDelta(t, 0, nearby[0].DistanceKm, 0.001)
+		assert.InDelta(t, 29.96, nearby[1].DistanceKm, 0.1)
+
+		limited, err := repo.FindNearby(ctx, domain.NearbyQuery{Latitude: 25.25, Longitude: 55.27, RadiusKm: 200, Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, limited, 1)
+	})
+}
+
+func TestPostgresRepositoryList(t *testing.T) {
+	t.Run("list ports with filters and keyset pagination", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEAJM"), Name: "Ajman", Country: "United Arab Emirates", Regions: []string{"Gulf"}, Unlocs: []string{"AEAJM"}},
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Country: "United Arab Emirates", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Country: "United Arab Emirates", Regions: []string{"Gulf"}, Unlocs: []string{"AEDXB"}},
+			{ID: stringPtr("CNBJO"), Name: "Beijiao", Country: "China", Unlocs: []string{"CNBJO"}},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+
+		first, err := repo.List(ctx, domain.ListQuery{Country: "united arab emirates", Sort: "-name", Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, first.Ports, 2)
+		assert.Equal(t, "Dubai", first.Ports[0].Name)
+		assert.Equal(t, "Ajman", first.Ports[1].Name)
+		assert.NotEmpty(t, first.NextCursor)
+
+		second, err := repo.List(ctx, domain.ListQuery{Country: "united arab emirates", Sort: "-name", Limit: 2, Cursor: first.NextCursor})
+		assert.NoError(t, err)
+		assert.Len(t, second.Ports, 1)
+		assert.Equal(t, "Abu Dhabi", second.Ports[0].Name)
+		assert.Empty(t, second.NextCursor)
+
+		byRegion, err := repo.List(ctx, domain.ListQuery{Region: "Gulf", Sort: "id", Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, byRegion.Ports, 2)
+
+		_, err = repo.List(ctx, domain.ListQuery{Sort: "name", Limit: 2, Cursor: first.NextCursor})
+		assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+	})
+}
+
+func TestPostgresRepositorySearch(t *testing.T) {
+	t.Run("search ports by misspelled name, alias and accented province", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", City: "Abu Dhabi", Province: "Abu Z¸aby [Abu Dhabi]", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", City: "Dubai", Province: "Dubayy [Dubai]", Unlocs: []string{"AEDXB"}},
+			{ID: stringPtr("CNCGU"), Name: "Changshu", City: "Changshu", Alias: []string{"Zhangjiagang", "Suzhou"}, Unlocs: []string{"CNCGU"}},
+			{ID: stringPtr("BRSSZ"), Name: "Santos", City: "São Paulo", Unlocs: []string{"BRSSZ"}},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+
+		results, err := repo.Search(ctx, domain.SearchQuery{Term: "abu dabi", Limit: 10})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, results)
+		assert.Equal(t, "AEAUH", *results[0].ID)
+		assert.Greater(t, results[0].Score, 0.5)
+
+		results, err = repo.Search(ctx, domain.SearchQuery{Term: "abu zaby", Limit: 10})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, results)
+		assert.Equal(t, "AEAUH", *results[0].ID)
+
+		results, err = repo.Search(ctx, domain.SearchQuery{Term: "suzhou", Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "CNCGU", *results[0].ID)
+
+		results, err = repo.Search(ctx, domain.SearchQuery{Term: "sao paulo", Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "BRSSZ", *results[0].ID)
+	})
+}
+
+func TestPostgresRepositoryHistory(t *testing.T) {
+	t.Run("every write is recorded with its source and diff", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		port := domain.Port{ID: stringPtr("AEDXB"), Name: "Dubai", Timezone: "Asia/Muscat", Unlocs: []string{"AEDXB"}}
+		importCtx := domain.WithChangeSource(ctx, domain.ChangeSource{Kind: domain.ChangeSourceImport, Actor: "ports.json", RunID: "run-1"})
+		_, err := repo.SaveBulk(importCtx, []domain.Port{port})
+		assert.NoError(t, err)
+
+		// saving the same values again is not a change
+		_, err = repo.SaveBulk(importCtx, []domain.Port{port})
+		assert.NoError(t, err)
+
+		port.Timezone = "Asia/Dubai"
+		httpCtx := domain.WithChangeSource(ctx, domain.ChangeSource{Kind: domain.ChangeSourceHTTP, Actor: "jane"})
+		_, err = repo.SaveBulk(httpCtx, []domain.Port{port})
+		assert.NoError(t, err)
+
+		// an actor longer than the column is cut, not refused
+		other := domain.Port{ID: stringPtr("AEAJM"), Name: "Ajman", Unlocs: []string{"AEAJM"}}
+		longCtx := domain.WithChangeSource(ctx, domain.ChangeSource{Kind: domain.ChangeSourceHTTP, Actor: strings.Repeat("é", 300)})
+		_, err = repo.SaveBulk(longCtx, []domain.Port{other})
+		require.NoError(t, err)
+		otherHistory, err := repo.History(ctx, "AEAJM")
+		require.NoError(t, err)
+		require.Len(t, otherHistory, 1)
+		assert.Equal(t, strings.Repeat("é", 255), otherHistory[0].Actor)
+
+		// the service resolves the ID, the history is matched exactly
+		wrongCase, err := repo.History(ctx, "aedxb")
+		assert.NoError(t, err)
+		assert.Empty(t, wrongCase)
+
+		history, err := repo.History(ctx, "AEDXB")
+		assert.NoError(t, err)
+		require.Len(t, history, 2)
+
+		assert.Equal(t, "update", history[0].Operation)
+		assert.Equal(t, domain.ChangeSourceHTTP, history[0].Source)
+		assert.Equal(t, "jane", history[0].Actor)
+		// the version bumped by the update is not part of the diff
+		assert.Equal(t, map[string]domain.FieldChange{"timezone": {Old: "Asia/Muscat", New: "Asia/Dubai"}}, history[0].Diff)
+		require.NotNil(t, history[0].Previous)
+		assert.Equal(t, "Asia/Muscat", history[0].Previous.Timezone)
+
+		assert.Equal(t, "insert", history[1].Operation)
+		assert.Equal(t, domain.ChangeSourceImport, history[1].Source)
+		assert.Equal(t, "run-1", history[1].RunID)
+		assert.Nil(t, history[1].Previous)
+
+		empty, err := repo.History(ctx, "XXXXX")
+		assert.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+}
+
+func TestPostgresRepositorySoftDelete(t *testing.T) {
+	t.Run("deleted ports are hidden until restored and purged after the cutoff", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Country: "United Arab Emirates", Unlocs: []string{"AEDXB"}},
+			{ID: stringPtr("AEAJM"), Name: "Ajman", Country: "United Arab Emirates", Unlocs: []string{"AEAJM"}},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.Delete(ctx, "aedxb"))
+		assert.ErrorIs(t, repo.Delete(ctx, "AEDXB"), domain.ErrPortNotFound)
+
+		_, err = repo.FindByID(ctx, "AEDXB")
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+
+		page, err := repo.List(ctx, domain.ListQuery{Sort: "id", Limit: 10})
+		assert.NoError(t, err)
+		require.Len(t, page.Ports, 1)
+		assert.Equal(t, "AEAJM", *page.Ports[0].ID)
+
+		assert.NoError(t, repo.Restore(ctx, "AEDXB"))
+		assert.ErrorIs(t, repo.Restore(ctx, "AEDXB"), domain.ErrPortNotFound)
+		_, err = repo.FindByID(ctx, "AEDXB")
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.Delete(ctx, "AEDXB"))
+		purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+
+		purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.ErrorIs(t, repo.Restore(ctx, "AEDXB"), domain.ErrPortNotFound)
+
+		history, err := repo.History(ctx, "AEDXB")
+		assert.NoError(t, err)
+		var operations []string
+		for _, change := range history {
+			operations = append(operations, change.Operation)
+		}
+		assert.Equal(t, []string{"purge", "delete", "restore", "delete", "insert"}, operations)
+	})
+
+	t.Run("conditional delete and restore only succeed on the stored version", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		_, err := repo.SaveBulk(ctx, []domain.Port{{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}}})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, repo.DeleteIfVersion(ctx, "AEDXB", 2), domain.ErrVersionConflict)
+		assert.ErrorIs(t, repo.DeleteIfVersion(ctx, "XXXXX", 1), domain.ErrPortNotFound)
+		assert.ErrorIs(t, repo.RestoreIfVersion(ctx, "AEDXB", 1), domain.ErrPortNotFound)
+		assert.NoError(t, repo.DeleteIfVersion(ctx, "aedxb", 1))
+
+		// deleting the port bumped its version
+		assert.ErrorIs(t, repo.RestoreIfVersion(ctx, "AEDXB", 1), domain.ErrVersionConflict)
+		assert.NoError(t, repo.RestoreIfVersion(ctx, "AEDXB", 2))
+
+		stored, err := repo.FindByID(ctx, "AEDXB")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stored.Version)
+	})
+}
+
+func TestPostgresRepositorySaveIfVersion(t *testing.T) {
+	t.Run("compare-and-swap update only succeeds on the stored version", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		port := domain.Port{ID: stringPtr("AEDXB"), Name: "Dubai", Timezone: "Asia/Muscat", Unlocs: []string{"AEDXB"}}
+		_, err := repo.SaveBulk(ctx, []domain.Port{port})
+		assert.NoError(t, err)
+
+		// unchanged upserts keep the version
+		_, err = repo.SaveBulk(ctx, []domain.Port{port})
+		assert.NoError(t, err)
+
+		port.Timezone = "Asia/Dubai"
+		version, err := repo.SaveIfVersion(ctx, port, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), version)
+
+		port.Name = "Dubai Port"
+		_, err = repo.SaveIfVersion(ctx, port, 1)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+
+		stored, err := repo.FindByID(ctx, "AEDXB")
+		assert.NoError(t, err)
+		assert.Equal(t, "Dubai", stored.Name)
+		assert.Equal(t, "Asia/Dubai", stored.Timezone)
+		assert.Equal(t, int64(2), stored.Version)
+
+		// SaveBulk bumps the version as well
+		_, err = repo.SaveBulk(ctx, []domain.Port{port})
+		assert.NoError(t, err)
+		stored, err = repo.FindByID(ctx, "AEDXB")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), stored.Version)
+
+		_, err = repo.SaveIfVersion(ctx, domain.Port{ID: stringPtr("XXXXX"), Unlocs: []string{"XXXXX"}}, 1)
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+	})
+}
+
+func TestPostgresRepositorySaveBulkContentHash(t *testing.T) {
+	t.Run("unchanged ports should not be written again", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEAJM"), Name: "Ajman", Timezone: "Asia/Dubai", Unlocs: []string{"AEAJM"}},
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Timezone: "Asia/Muscat", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}},
+		}
+		result, err := repo.SaveBulk(ctx, ports)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SaveResult{Created: 3}, result)
+
+		result, err = repo.SaveBulk(ctx, ports)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SaveResult{Unchanged: 3}, result)
+
+		history, err := repo.History(ctx, "AEAJM")
+		require.NoError(t, err)
+		assert.Len(t, history, 1)
+
+		// a changed port is updated and a deleted one is skipped, it stays deleted
+		ports[1].Timezone = "Asia/Dubai"
+		ports[2].Name = "Dubai Creek"
+		require.NoError(t, repo.Delete(ctx, "AEDXB"))
+		result, err = repo.SaveBulk(ctx, ports)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SaveResult{Updated: 1, Unchanged: 1, Skipped: 1}, result)
+
+		deletedIDs, err := repo.DeletedIDs(ctx, []string{"AEAJM", "AEDXB", "XXXXX"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"AEDXB"}, deletedIDs)
+
+		stored, err := repo.FindByID(ctx, "AEAJM")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), stored.Version)
+		stored, err = repo.FindByID(ctx, "AEAUH")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored.Version)
+		assert.Equal(t, "Asia/Dubai", stored.Timezone)
+		_, err = repo.FindByID(ctx, "AEDXB")
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+		require.NoError(t, repo.Restore(ctx, "AEDXB"))
+		restored, err := repo.FindByID(ctx, "AEDXB")
+		require.NoError(t, err)
+		assert.Equal(t, "Dubai", restored.Name)
+	})
+}
+
+func TestPostgresRepositoryFindByIdentifier(t *testing.T) {
+	t.Run("find ports by id, secondary unloc, alias and code", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("CNDLC"), Name: "Dalian", Unlocs: []string{"CNDLC", "CNDAL"}, Code: "57000"},
+			{ID: stringPtr("CNDAL"), Name: "Dali", Unlocs: []string{"CNDAL"}, Code: "57000"},
+			{ID: stringPtr("CNNGB"), Name: "Ningbo", Alias: []string{"Ningpo"}, Unlocs: []string{"CNNGB", "CNNBO"}, Code: "57020"},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+
+		// the id wins over a secondary unloc
+		port, err := repo.FindByIdentifier(ctx, "cndal")
+		assert.NoError(t, err)
+		assert.Equal(t, "CNDAL", *port.ID)
+
+		port, err = repo.FindByIdentifier(ctx, "cnnbo")
+		assert.NoError(t, err)
+		assert.Equal(t, "CNNGB", *port.ID)
+
+		port, err = repo.FindByIdentifier(ctx, "NINGPO")
+		assert.NoError(t, err)
+		assert.Equal(t, "CNNGB", *port.ID)
+
+		_, err = repo.FindByIdentifier(ctx, "XXXXX")
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+
+		byCode, err := repo.FindByCode(ctx, "57000")
+		assert.NoError(t, err)
+		require.Len(t, byCode, 2)
+		assert.Equal(t, "CNDAL", *byCode[0].ID)
+		assert.Equal(t, "CNDLC", *byCode[1].ID)
+
+		assert.NoError(t, repo.Delete(ctx, "CNNGB"))
+		byIDs, err := repo.FindByIDs(ctx, []string{"CNDLC", "CNNGB", "cndal", "XXXXX"})
+		assert.NoError(t, err)
+		require.Len(t, byIDs, 1)
+		assert.Equal(t, "Dalian", byIDs["CNDLC"].Name)
+	})
+}
+
+func TestPostgresRepositoryCountries(t *testing.T) {
+	t.Run("count and list ports by country code", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Country: "United Arab Emirates", CountryCode: "AE", SubdivisionCode: "AE-AZ", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Country: "United Arab Emirates", CountryCode: "AE", SubdivisionCode: "AE-DU", Unlocs: []string{"AEDXB"}},
+			{ID: stringPtr("ANCUR"), Name: "Willemstad", Country: "Netherlands Antilles", Unlocs: []string{"ANCUR"}},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+
+		counts, err := repo.CountByCountry(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"AE": 2}, counts)
+
+		page, err := repo.List(ctx, domain.ListQuery{CountryCode: "ae", Sort: "id", Limit: 10})
+		assert.NoError(t, err)
+		require.Len(t, page.Ports, 2)
+		assert.Equal(t, "AE-AZ", page.Ports[0].SubdivisionCode)
+
+		port, err := repo.FindByID(ctx, "ANCUR")
+		assert.NoError(t, err)
+		assert.Empty(t, port.CountryCode)
+	})
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestPostgresRepositoryDeleteMissing(t *testing.T) {
+	t.Run("ports missing from a list are found and soft-deleted", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		ports := []domain.Port{
+			{ID: stringPtr("AEAJM"), Name: "Ajman", Unlocs: []string{"AEAJM"}},
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}},
+		}
+		_, err := repo.SaveBulk(ctx, ports)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Delete(ctx, "AEDXB"))
+
+		count, err := repo.Count(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		missing, err := repo.MissingIDs(ctx, []string{"AEAJM"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AEAUH"}, missing)
+
+		deleted, err := repo.DeleteMany(ctx, missing)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		_, err = repo.FindByID(ctx, "AEAUH")
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+	})
+}
+
+func TestPostgresRepositoryCheckpoints(t *testing.T) {
+	t.Run("checkpoints are saved, updated and deleted", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		_, err := repo.FindCheckpoint(ctx, "sha256:abc")
+		assert.ErrorIs(t, err, domain.ErrCheckpointNotFound)
+
+		checkpoint := domain.Checkpoint{Fingerprint: "sha256:abc", Source: "ports.json", LastKey: "AEAJM", Offset: 120, Imported: 200}
+		assert.NoError(t, repo.SaveCheckpoint(ctx, checkpoint))
+		checkpoint.LastKey, checkpoint.Offset, checkpoint.Imported = "AEDXB", 4096, 400
+		assert.NoError(t, repo.SaveCheckpoint(ctx, checkpoint))
+
+		found, err := repo.FindCheckpoint(ctx, "sha256:abc")
+		require.NoError(t, err)
+		assert.Equal(t, "AEDXB", found.LastKey)
+		assert.Equal(t, int64(4096), found.Offset)
+		assert.Equal(t, int64(400), found.Imported)
+
+		assert.NoError(t, repo.DeleteCheckpoint(ctx, "sha256:abc"))
+		_, err = repo.FindCheckpoint(ctx, "sha256:abc")
+		assert.ErrorIs(t, err, domain.ErrCheckpointNotFound)
+	})
+}
+
+func TestPostgresRepositoryCopyBulk(t *testing.T) {
+	t.Run("copy bulk should create, update and skip deleted ports like save bulk", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		_, err := repo.SaveBulk(ctx, []domain.Port{
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Timezone: "Asia/Muscat", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, "AEDXB"))
+
+		ports := []domain.Port{
+			{
+				ID:          stringPtr("AEAJM"),
+				Name:        "Ajman",
+				City:        "Ajman",
+				Country:     "United Arab Emirates",
+				CountryCode: "AE",
+				Alias:       []string{},
+				Regions:     []string{"Gulf"},
+				Coordinates: []float64{55.5136433, 25.4052165},
+				Province:    "Ajman",
+				Timezone:    "Asia/Dubai",
+				Unlocs:      []string{"AEAJM"},
+				Code:        "52000",
+			},
+			{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Timezone: "Asia/Dubai", Unlocs: []string{"AEAUH"}},
+			{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}},
+		}
+		result, err := repo.CopyBulk(ctx, ports)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SaveResult{Created: 1, Updated: 1, Skipped: 1}, result)
+
+		created, err := repo.FindByID(ctx, "AEAJM")
+		require.NoError(t, err)
+		ports[0].Version = 1
+		assert.Equal(t, ports[0], *created)
+
+		updated, err := repo.FindByID(ctx, "AEAUH")
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Dubai", updated.Timezone)
+		assert.Empty(t, updated.CountryCode)
+
+		_, err = repo.FindByID(ctx, "AEDXB")
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+	})
+}
+
+// The benchmarks compare the loaders on batches of the default size and on the largest batch an INSERT
+// takes, run them with: go test -tags=integration -run=^$ -bench=Bulk ./internal/infra/adapters/repository/
+// Past the first round the ports are unchanged, the benchmarks measure re-imports of the same file.
+func BenchmarkSaveBulk(b *testing.B) {
+	benchmarkLoader(b, func(repo domain.RepositoryPort) func(context.Context, []domain.Port) (domain.SaveResult, error) {
+		return repo.SaveBulk
+	})
+}
+
+func BenchmarkCopyBulk(b *testing.B) {
+	benchmarkLoader(b, func(repo domain.RepositoryPort) func(context.Context, []domain.Port) (domain.SaveResult, error) {
+		return repo.CopyBulk
+	})
+}
+
+func benchmarkLoader(b *testing.B, loader func(domain.RepositoryPort) func(context.Context, []domain.Port) (domain.SaveResult, error)) {
+	ctx := context.Background()
+	postgresContainer, db := suite.SetupPostgresContainer(b)
+	defer postgresContainer.Terminate(ctx)
+	defer db.Close()
+
+	save := loader(NewPostgresRepository(db))
+	for _, size := range []int{domain.DefaultImportBatchSize, 5000} {
+		ports := make([]domain.Port, size)
+		for i := range ports {
+			id := fmt.Sprintf("B%04d", i)
+			ports[i] = domain.Port{
+				ID:          &id,
+				Name:        "Port " + id,
+				City:        "City " + id,
+				Country:     "United Arab Emirates",
+				CountryCode: "AE",
+				Alias:       []string{"Alias " + id},
+				Regions:     []string{"Gulf"},
+				Coordinates: []float64{55.5136433, 25.4052165},
+				Timezone:    "Asia/Dubai",
+				Unlocs:      []string{id},
+				Code:        "52000",
+			}
+		}
+
+		b.Run(fmt.Sprintf("%d ports", size), func(b *testing.B) {
+			for range b.N {
+				if _, err := save(ctx, ports); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*size)/b.Elapsed().Seconds(), "ports/s")
+		})
+	}
+}
+
+func TestPostgresRepositoryInTransaction(t *testing.T) {
+	t.Run("changes should be visible to others only once the transaction commits", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+
+		err := repo.InTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.SaveBulk(ctx, []domain.Port{{ID: stringPtr("AEAJM"), Name: "Ajman", Unlocs: []string{"AEAJM"}}})
+			require.NoError(t, err)
+			_, err = repo.CopyBulk(ctx, []domain.Port{{ID: stringPtr("AEAUH"), Name: "Abu Dhabi", Unlocs: []string{"AEAUH"}}})
+			require.NoError(t, err)
+			_, err = repo.CopyBulk(ctx, []domain.Port{{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}}})
+			require.NoError(t, err)
+
+			// a refused batch is undone alone, the transaction goes on
+			tooLong := domain.Port{ID: stringPtr("AEFJR"), Code: "12345678901", Unlocs: []string{"AEFJR"}}
+			_, err = repo.SaveBulk(ctx, []domain.Port{tooLong})
+			assert.Error(t, err)
+
+			count, err := repo.Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), count)
+
+			_, err = repo.FindByID(context.Background(), "AEAJM")
+			assert.ErrorIs(t, err, domain.ErrPortNotFound)
+			return nil
+		})
+		require.NoError(t, err)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("changes should be rolled back when the function fails", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+		_, err := repo.SaveBulk(ctx, []domain.Port{{ID: stringPtr("AEDXB"), Name: "Dubai", Unlocs: []string{"AEDXB"}}})
+		require.NoError(t, err)
+
+		failure := errors.New("import failed")
+		err = repo.InTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.SaveBulk(ctx, []domain.Port{{ID: stringPtr("AEAJM"), Name: "Ajman", Unlocs: []string{"AEAJM"}}})
+			require.NoError(t, err)
+			_, err = repo.DeleteMany(ctx, []string{"AEDXB"})
+			require.NoError(t, err)
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		_, err = repo.FindByID(ctx, "AEAJM")
+		assert.ErrorIs(t, err, domain.ErrPortNotFound)
+		_, err = repo.FindByID(ctx, "AEDXB")
+		assert.NoError(t, err)
+	})
+}
+
+func TestPostgresRepositoryImportJobs(t *testing.T) {
+	t.Run("import jobs are created, updated, canceled and finished", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+		_, err := repo.FindImportJob(ctx, "abc")
+		assert.ErrorIs(t, err, domain.ErrImportJobNotFound)
+		assert.ErrorIs(t, repo.RequestImportJobCancel(ctx, "abc"), domain.ErrImportJobNotFound)
+
+		require.NoError(t, repo.CreateImportJob(ctx, domain.ImportJob{ID: "abc", Status: domain.ImportJobRunning, Format: "json", Source: "ports.json"}))
+
+		cancelRequested, err := repo.SaveImportJobProgress(ctx, "abc", domain.ImportSummary{Imported: 200})
+		require.NoError(t, err)
+		assert.False(t, cancelRequested)
+
+		require.NoError(t, repo.RequestImportJobCancel(ctx, "abc"))
+		cancelRequested, err = repo.SaveImportJobProgress(ctx, "abc", domain.ImportSummary{Imported: 400, Rejected: 1})
+		require.NoError(t, err)
+		assert.True(t, cancelRequested)
+
+		job, err := repo.FindImportJob(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportJobRunning, job.Status)
+		assert.Equal(t, "ports.json", job.Source)
+		assert.Equal(t, domain.ImportSummary{Imported: 400, Rejected: 1}, job.Summary)
+		assert.True(t, job.CancelRequested)
+		assert.Nil(t, job.FinishedAt)
+
+		require.NoError(t, repo.FinishImportJob(ctx, domain.ImportJob{
+			ID:      "abc",
+			Status:  domain.ImportJobCanceled,
+			Summary: domain.ImportSummary{Imported: 450, Rejected: 1},
+			Error:   "canceled on request",
+		}))
+		assert.ErrorIs(t, repo.RequestImportJobCancel(ctx, "abc"), domain.ErrImportJobFinished)
+
+		job, err = repo.FindImportJob(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportJobCanceled, job.Status)
+		assert.Equal(t, int64(450), job.Summary.Imported)
+		assert.Equal(t, "canceled on request", job.Error)
+		assert.NotNil(t, job.FinishedAt)
+		assert.True(t, job.Finished())
+	})
+
+	t.Run("stale running jobs and their runs are finished", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+		for _, id := range []string{"stale", "canceled", "live"} {
+			require.NoError(t, repo.CreateImportJob(ctx, domain.ImportJob{ID: id, Status: domain.ImportJobRunning, Format: "json", Source: "ports.json"}))
+			require.NoError(t, repo.CreateImportRun(ctx, domain.ImportRun{ID: id, Source: "ports.json", Format: "json", Mode: "upsert",
+				Outcome: domain.ImportRunning, StartedAt: time.Now()}))
+		}
+		_, err := repo.SaveImportJobProgress(ctx, "stale", domain.ImportSummary{Imported: 200, Created: 200})
+		require.NoError(t, err)
+		require.NoError(t, repo.RequestImportJobCancel(ctx, "canceled"))
+		_, err = db.ExecContext(ctx, `UPDATE import_jobs SET updated_at = now() - interval '1 hour' WHERE id <> 'live'`)
+		require.NoError(t, err)
+
+		finished, err := repo.FinishStaleImportJobs(ctx, time.Now().Add(-time.Minute), "abandoned")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), finished)
+
+		job, err := repo.FindImportJob(ctx, "stale")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportJobFailed, job.Status)
+		assert.Equal(t, "abandoned", job.Error)
+		job, err = repo.FindImportJob(ctx, "canceled")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportJobCanceled, job.Status)
+		job, err = repo.FindImportJob(ctx, "live")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ImportJobRunning, job.Status)
+
+		runs, err := repo.ListImportRuns(ctx, 10)
+		require.NoError(t, err)
+		outcomes := map[string]domain.ImportRun{}
+		for _, run := range runs {
+			outcomes[run.ID] = run
+		}
+		assert.Equal(t, domain.ImportFailed, outcomes["stale"].Outcome)
+		assert.Equal(t, domain.ImportSummary{Imported: 200, Created: 200}, outcomes["stale"].Summary)
+		assert.NotNil(t, outcomes["stale"].FinishedAt)
+		assert.Equal(t, domain.ImportCanceled, outcomes["canceled"].Outcome)
+		assert.Equal(t, domain.ImportRunning, outcomes["live"].Outcome)
+	})
+}
+
+func TestPostgresRepositoryImportRuns(t *testing.T) {
+	t.Run("import runs are recorded and listed newest first", func(t *testing.T) {
+		ctx := context.Background()
+		postgresContainer, db := suite.SetupPostgresContainer(t)
+		defer postgresContainer.Terminate(ctx)
+		defer db.Close()
+
+		repo := NewPostgresRepository(db)
+		_, err := repo.FindLastImportRun(ctx)
+		assert.ErrorIs(t, err, domain.ErrImportRunNotFound)
+
+		started := time.Now().UTC().Truncate(time.Microsecond)
+		runs := []domain.ImportRun{
+			{ID: "a", Source: "ports.json", Format: "json", Mode: "upsert", Size: 1 << 32, Checksum: "sha256:abc", Outcome: domain.ImportRunning, StartedAt: started},
+			{ID: "b", Source: "stdin", Format: "ndjson", Mode: "upsert", DryRun: true, Outcome: domain.ImportRunning, StartedAt: started.Add(time.Second)},
+			{ID: "c", Source: "ports.json", Format: "json", Mode: "upsert", Checksum: "sha256:abc", Outcome: domain.ImportUnchanged, StartedAt: started.Add(2 * time.Second)},
+		}
+		for _, run := range runs {
+			require.NoError(t, repo.CreateImportRun(ctx, run))
+		}
+
+		finished := started.Add(time.Minute)
+		runs[0].Outcome, runs[0].FinishedAt = domain.ImportSucceeded, &finished
+		runs[0].Summary = domain.ImportSummary{Parsed: 10, Imported: 8, Rejected: 2}
+		require.NoError(t, repo.FinishImportRun(ctx, runs[0]))
+		assert.ErrorIs(t, repo.FinishImportRun(ctx, domain.ImportRun{ID: "unknown", FinishedAt: &finished}), domain.ErrImportRunNotFound)
+
+		// the dry run and the unchanged file do not count as imports
+		last, err := repo.FindLastImportRun(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "a", last.ID)
+		assert.Equal(t, int64(1<<32), last.Size)
+		assert.Equal(t, domain.ImportSummary{Parsed: 10, Imported: 8, Rejected: 2}, last.Summary)
+		assert.True(t, finished.Equal(*last.FinishedAt))
+
+		listed, err := repo.ListImportRuns(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+		assert.Equal(t, "c", listed[0].ID)
+		assert.Equal(t, "b", listed[1].ID)
+		assert.Empty(t, listed[1].Checksum)
+		assert.Zero(t, listed[1].Size)
+		assert.Nil(t, listed[1].FinishedAt)
+
+		// the standard input is measured once it is read
+		runs[1].Outcome, runs[1].FinishedAt = domain.ImportSucceeded, &finished
+		runs[1].Size, runs[1].Checksum = 42, "sha256:def"
+		require.NoError(t, repo.FinishImportRun(ctx, runs[1]))
+		listed, err = repo.ListImportRuns(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:def", listed[1].Checksum)
+		assert.Equal(t, int64(42), listed[1].Size)
+	})
+}
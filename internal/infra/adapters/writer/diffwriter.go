@@ -0,0 +1,128 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// DiffFormat is the format of a dry-run report.
+type DiffFormat string
+
+const (
+	DiffText DiffFormat = "text"
+	DiffJSON DiffFormat = "json"
+)
+
+// DiffWriter writes the report of a dry-run import: the ports it would create or update as they come,
+// then the totals on Close.
+//
+// The text format has a line per created port ("+ AEAJM"), per deleted port ("- AEAJM") and per updated
+// port ("~ AEAJM") followed by its changed fields, and a last line with the totals. The JSON format is a single object,
+// {"ports": [PortDiff...], "summary": ImportSummary}, with an "error" when the import failed.
+type DiffWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	format  DiffFormat
+	written int
+}
+
+func NewDiffWriter(w io.Writer, format DiffFormat) (*DiffWriter, error) {
+	if format != DiffText && format != DiffJSON {
+		return nil, fmt.Errorf("unknown report format %q, expected %s or %s", format, DiffText, DiffJSON)
+	}
+	return &DiffWriter{w: w, format: format}, nil
+}
+
+func (d *DiffWriter) Diff(_ context.Context, diff domain.PortDiff) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.format == DiffJSON {
+		raw, err := json.Marshal(diff)
+		if err != nil {
+			return fmt.Errorf("error encoding diff: %w", err)
+		}
+		prefix := ","
+		if d.written == 0 {
+			prefix = `{"ports":[`
+		}
+		d.written++
+		_, err = fmt.Fprintf(d.w, "%s%s", prefix, raw)
+		return err
+	}
+
+	d.written++
+	switch diff.Action {
+	case domain.DiffCreated:
+		_, err := fmt.Fprintf(d.w, "+ %s\n", diff.ID)
+		return err
+	case domain.DiffDeleted:
+		_, err := fmt.Fprintf(d.w, "- %s\n", diff.ID)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(d.w, "~ %s\n", diff.ID); err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(diff.Changes))
+	for field := range diff.Changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		change := diff.Changes[field]
+		before, _ := json.Marshal(change.Old)
+		after, _ := json.Marshal(change.New)
+		if _, err := fmt.Fprintf(d.w, "    %s: %s -> %s\n", field, before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ends the report with the totals of the import.
+func (d *DiffWriter) Close(summary domain.ImportSummary) error {
+	return d.end(summary, nil)
+}
+
+// Fail ends the report of a failed import with the totals reached and the error, so a JSON report
+// stays a single document.
+func (d *DiffWriter) Fail(summary domain.ImportSummary, cause error) error {
+	return d.end(summary, cause)
+}
+
+func (d *DiffWriter) end(summary domain.ImportSummary, cause error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.format == DiffJSON {
+		raw, err := json.Marshal(summary)
+		if err != nil {
+			return fmt.Errorf("error encoding summary: %w", err)
+		}
+		prefix := "]"
+		if d.written == 0 {
+			prefix = `{"ports":[]`
+		}
+		suffix := ""
+		if cause != nil {
+			reason, _ := json.Marshal(cause.Error())
+			suffix = fmt.Sprintf(",\"error\":%s", reason)
+		}
+		_, err = fmt.Fprintf(d.w, "%s,\"summary\":%s%s}\n", prefix, raw, suffix)
+		return err
+	}
+
+	_, err := fmt.Fprintf(d.w, "%d to create, %d to update, %d unchanged, %d to delete, %d rejected\n",
+		summary.Created, summary.Updated, summary.Unchanged, summary.Deleted, summary.Rejected)
+	if err == nil && cause != nil {
+		_, err = fmt.Fprintf(d.w, "failed: %s\n", cause)
+	}
+	return err
+}
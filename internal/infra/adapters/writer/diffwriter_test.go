@@ -0,0 +1,105 @@
+//go:build unit
+
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffWriter(t *testing.T) {
+	ctx := context.Background()
+	diffs := []domain.PortDiff{
+		{ID: "AEAJM", Action: domain.DiffCreated},
+		{ID: "AEAUH", Action: domain.DiffUpdated, Changes: map[string]domain.FieldChange{
+			"timezone": {Old: "Asia/Muscat", New: "Asia/Dubai"},
+			"alias":    {Old: []string{}, New: []string{"Abu Zaby"}},
+		}},
+		{ID: "AEDXB", Action: domain.DiffDeleted},
+	}
+	summary := domain.ImportSummary{Created: 1, Updated: 1, Unchanged: 3, Deleted: 1, Rejected: 1}
+
+	t.Run("text report should list the ports and the totals", func(t *testing.T) {
+		var buf bytes.Buffer
+		report, err := NewDiffWriter(&buf, DiffText)
+		require.NoError(t, err)
+
+		for _, diff := range diffs {
+			require.NoError(t, report.Diff(ctx, diff))
+		}
+		require.NoError(t, report.Close(summary))
+
+		assert.Equal(t, "+ AEAJM\n"+
+			"~ AEAUH\n"+
+			"    alias: [] -> [\"Abu Zaby\"]\n"+
+			"    timezone: \"Asia/Muscat\" -> \"Asia/Dubai\"\n"+
+			"- AEDXB\n"+
+			"1 to create, 1 to update, 3 unchanged, 1 to delete, 1 rejected\n", buf.String())
+	})
+
+	t.Run("json report should be a single document", func(t *testing.T) {
+		var buf bytes.Buffer
+		report, err := NewDiffWriter(&buf, DiffJSON)
+		require.NoError(t, err)
+
+		for _, diff := range diffs {
+			require.NoError(t, report.Diff(ctx, diff))
+		}
+		require.NoError(t, report.Close(summary))
+
+		assert.JSONEq(t, `{
+			"ports": [
+				{"id": "AEAJM", "action": "created"},
+				{"id": "AEAUH", "action": "updated", "changes": {
+					"alias": {"old": [], "new": ["Abu Zaby"]},
+					"timezone": {"old": "Asia/Muscat", "new": "Asia/Dubai"}
+				}},
+				{"id": "AEDXB", "action": "deleted"}
+			],
+			"summary": {"imported": 0, "rejected": 1, "created": 1, "updated": 1, "unchanged": 3, "deleted": 1}
+		}`, buf.String())
+	})
+
+	t.Run("json report without changes should have no ports", func(t *testing.T) {
+		var buf bytes.Buffer
+		report, err := NewDiffWriter(&buf, DiffJSON)
+		require.NoError(t, err)
+
+		require.NoError(t, report.Close(domain.ImportSummary{Unchanged: 2}))
+		assert.JSONEq(t, `{"ports": [], "summary": {"imported": 0, "rejected": 0, "created": 0, "updated": 0, "unchanged": 2, "deleted": 0}}`, buf.String())
+	})
+
+	t.Run("failed json report should still be a single document", func(t *testing.T) {
+		var buf bytes.Buffer
+		report, err := NewDiffWriter(&buf, DiffJSON)
+		require.NoError(t, err)
+
+		require.NoError(t, report.Diff(ctx, diffs[0]))
+		require.NoError(t, report.Fail(domain.ImportSummary{Created: 1}, errors.New("invalid port")))
+		assert.JSONEq(t, `{
+			"ports": [{"id": "AEAJM", "action": "created"}],
+			"summary": {"imported": 0, "rejected": 0, "created": 1, "updated": 0, "unchanged": 0, "deleted": 0},
+			"error": "invalid port"
+		}`, buf.String())
+	})
+
+	t.Run("failed text report should end with the error", func(t *testing.T) {
+		var buf bytes.Buffer
+		report, err := NewDiffWriter(&buf, DiffText)
+		require.NoError(t, err)
+
+		require.NoError(t, report.Fail(domain.ImportSummary{Unchanged: 2}, errors.New("invalid port")))
+		assert.Equal(t, "0 to create, 0 to update, 2 unchanged, 0 to delete, 0 rejected\nfailed: invalid port\n", buf.String())
+	})
+
+	t.Run("unknown format should return error", func(t *testing.T) {
+		_, err := NewDiffWriter(&bytes.Buffer{}, "yaml")
+		assert.Error(t, err)
+	})
+}
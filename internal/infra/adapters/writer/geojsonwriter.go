@@ -0,0 +1,152 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// GeoJSONContentType is the media type of GeoJSON documents (RFC 7946).
+const GeoJSONContentType = "application/geo+json"
+
+// GeoJSONWriter streams ports as the features of a GeoJSON FeatureCollection, one feature per Write.
+// Nothing is buffered, Close ends the collection and must be called even when no port was written.
+type GeoJSONWriter struct {
+	w       io.Writer
+	started bool
+	members map[string]interface{}
+	err     error
+}
+
+func NewGeoJSONWriter(w io.Writer) *GeoJSONWriter {
+	return &GeoJSONWriter{w: w}
+}
+
+// AddMember adds a foreign member to the FeatureCollection, e.g. the cursor of the next page.
+// Members are written by Close, after the features.
+func (g *GeoJSONWriter) AddMember(name string, value interface{}) {
+	if g.members == nil {
+		g.members = map[string]interface{}{}
+	}
+	g.members[name] = value
+}
+
+// Write appends a port as a Feature: the ID is the feature id, the coordinates a Point and the other
+// fields, with the extra ones (e.g. distance_km), are the properties.
+func (g *GeoJSONWriter) Write(port domain.Port, extra map[string]interface{}) error {
+	if g.err != nil {
+		return g.err
+	}
+
+	feature, err := newFeature(port, extra)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(feature)
+	if err != nil {
+		return fmt.Errorf("error encoding port: %w", err)
+	}
+
+	prefix := ","
+	if !g.started {
+		prefix = `{"type":"FeatureCollection","features":[`
+		g.started = true
+	}
+	return g.write(prefix, string(raw))
+}
+
+// Close ends the FeatureCollection. It does not close the underlying writer.
+func (g *GeoJSONWriter) Close() error {
+	if g.err != nil {
+		return g.err
+	}
+
+	if !g.started {
+		if err := g.write(`{"type":"FeatureCollection","features":[`); err != nil {
+			return err
+		}
+		g.started = true
+	}
+	if err := g.write("]"); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(g.members))
+	for name := range g.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		raw, err := json.Marshal(g.members[name])
+		if err != nil {
+			return fmt.Errorf("error encoding member %s: %w", name, err)
+		}
+		if err := g.write(",", string(key), ":", string(raw)); err != nil {
+			return err
+		}
+	}
+
+	return g.write("}\n")
+}
+
+// WriteFeature writes a single port as a GeoJSON Feature.
+func WriteFeature(w io.Writer, port domain.Port) error {
+	feature, err := newFeature(port, nil)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(feature)
+}
+
+func (g *GeoJSONWriter) write(parts ...string) error {
+	for _, part := range parts {
+		if _, err := io.WriteString(g.w, part); err != nil {
+			g.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *geoJSONPoint          `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func newFeature(port domain.Port, extra map[string]interface{}) (geoJSONFeature, error) {
+	feature := geoJSONFeature{Type: "Feature"}
+	if port.ID != nil {
+		feature.ID = *port.ID
+	}
+	// ports without coordinates are features without geometry, GeoJSON allows a null one
+	if len(port.Coordinates) == 2 {
+		feature.Geometry = &geoJSONPoint{Type: "Point", Coordinates: port.Coordinates}
+	}
+
+	raw, err := json.Marshal(port)
+	if err != nil {
+		return feature, fmt.Errorf("error encoding port: %w", err)
+	}
+	if err := json.Unmarshal(raw, &feature.Properties); err != nil {
+		return feature, fmt.Errorf("error encoding port: %w", err)
+	}
+	delete(feature.Properties, "id")
+	delete(feature.Properties, "coordinates")
+	for name, value := range extra {
+		feature.Properties[name] = value
+	}
+
+	return feature, nil
+}
@@ -0,0 +1,84 @@
+//go:build unit
+
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type featureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Type     string `json:"type"`
+		ID       string `json:"id"`
+		Geometry *struct {
+			Type        string    `json:"type"`
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestGeoJSONWriter(t *testing.T) {
+	t.Run("ports should be written as point features", func(t *testing.T) {
+		ajman, abuDhabi := "AEAJM", "AEAUH"
+		var buf bytes.Buffer
+
+		geo := NewGeoJSONWriter(&buf)
+		require.NoError(t, geo.Write(domain.Port{
+			ID:          &ajman,
+			Name:        "Ajman",
+			CountryCode: "AE",
+			Coordinates: []float64{55.5136433, 25.4052165},
+			Unlocs:      []string{"AEAJM"},
+		}, map[string]interface{}{"distance_km": 12.5}))
+		require.NoError(t, geo.Write(domain.Port{ID: &abuDhabi, Name: "Abu Dhabi", Unlocs: []string{"AEAUH"}}, nil))
+		geo.AddMember("next_cursor", "next")
+		require.NoError(t, geo.Close())
+
+		var collection featureCollection
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+		assert.Equal(t, "FeatureCollection", collection.Type)
+		assert.Equal(t, "next", collection.NextCursor)
+		require.Len(t, collection.Features, 2)
+
+		first := collection.Features[0]
+		assert.Equal(t, "Feature", first.Type)
+		assert.Equal(t, "AEAJM", first.ID)
+		assert.Equal(t, "Point", first.Geometry.Type)
+		assert.Equal(t, []float64{55.5136433, 25.4052165}, first.Geometry.Coordinates)
+		assert.Equal(t, "Ajman", first.Properties["name"])
+		assert.Equal(t, "AE", first.Properties["country_code"])
+		assert.Equal(t, 12.5, first.Properties["distance_km"])
+		assert.NotContains(t, first.Properties, "coordinates")
+		assert.NotContains(t, first.Properties, "id")
+
+		assert.Nil(t, collection.Features[1].Geometry)
+	})
+
+	t.Run("empty collection should be valid", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, NewGeoJSONWriter(&buf).Close())
+		assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, buf.String())
+	})
+
+	t.Run("single port should be written as a feature", func(t *testing.T) {
+		id := "AEAJM"
+		var buf bytes.Buffer
+
+		require.NoError(t, WriteFeature(&buf, domain.Port{ID: &id, Coordinates: []float64{55.51, 25.40}}))
+
+		var feature map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &feature))
+		assert.Equal(t, "Feature", feature["type"])
+		assert.Equal(t, "AEAJM", feature["id"])
+	})
+}
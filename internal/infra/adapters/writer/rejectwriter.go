@@ -0,0 +1,46 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+// rejectedRecord is a line of the reject file.
+type rejectedRecord struct {
+	Key    string              `json:"key"`
+	Offset int64               `json:"offset"`
+	Reason string              `json:"reason"`
+	Fields []domain.FieldError `json:"fields,omitempty"`
+}
+
+// RejectWriter writes the records an import rejected as NDJSON, one {"key", "offset", "reason"} object
+// per line, with the invalid fields of the ports that failed validation.
+type RejectWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewRejectWriter(w io.Writer) domain.RejectPort {
+	return &RejectWriter{encoder: json.NewEncoder(w)}
+}
+
+func (r *RejectWriter) Reject(_ context.Context, record domain.RecordError) error {
+	line := rejectedRecord{Key: record.Key, Offset: record.Offset, Reason: record.Error()}
+	var verr *domain.ValidationError
+	if errors.As(record.Err, &verr) {
+		line.Fields = verr.Fields
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.encoder.Encode(line); err != nil {
+		return fmt.Errorf("error writing rejected record: %w", err)
+	}
+	return nil
+}
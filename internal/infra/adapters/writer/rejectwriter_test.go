@@ -0,0 +1,40 @@
+//go:build unit
+
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejectWriter(t *testing.T) {
+	var buf bytes.Buffer
+	rejects := NewRejectWriter(&buf)
+
+	require.NoError(t, rejects.Reject(context.Background(), domain.RecordError{
+		Key:    "line 2",
+		Offset: 22,
+		Err:    errors.New("invalid json: line 2: unexpected end of JSON input"),
+	}))
+	require.NoError(t, rejects.Reject(context.Background(), domain.RecordError{
+		Key:    "XXXXX",
+		Offset: 64,
+		Err:    &domain.ValidationError{Fields: []domain.FieldError{{Field: "unlocs[0]", Reason: "not a UN/LOCODE"}}},
+	}))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"key": "line 2", "offset": 22, "reason": "invalid json: line 2: unexpected end of JSON input"}`, string(lines[0]))
+	assert.JSONEq(t, `{
+		"key": "XXXXX",
+		"offset": 64,
+		"reason": "invalid port: unlocs[0]: not a UN/LOCODE",
+		"fields": [{"field": "unlocs[0]", "reason": "not a UN/LOCODE"}]
+	}`, string(lines[1]))
+}
@@ -1,9 +1,22 @@
 package handler
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+)
 
 var (
-	invalidRequest     = errors.New("invalid request")
-	internalServer     = errors.New("internal server error")
-	missingIDParameter = errors.New("missing id parameter")
+	invalidRequest       = errors.New("invalid request")
+	internalServer       = errors.New("internal server error")
+	missingCodeParameter = errors.New("missing code parameter")
+	missingIDParameter   = errors.New("missing id parameter")
+	invalidIfMatch       = errors.New("invalid If-Match header, expected a single ETag such as \"3\"")
+	notFound             = errors.New("not found")
+	unknownAction        = errors.New("unknown action")
+	missingImportFile    = errors.New("missing file part in the multipart body")
+	importTooLarge       = errors.New("import file too large")
+	importNameTooLong    = fmt.Errorf("import file name too long, at most %d characters", domain.MaxImportSourceLength)
+	unsupportedPatchType = errors.New("unsupported media type, use application/merge-patch+json or application/json-patch+json")
 )
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/writer"
+)
+
+// wantsGeoJSON tells whether the Accept header asks for GeoJSON. Responses depending on it vary on Accept.
+func wantsGeoJSON(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Add("Vary", "Accept")
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == writer.GeoJSONContentType && params["q"] != "0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeGeoJSON streams a FeatureCollection, features are written as they are encoded.
+func writeGeoJSON(w http.ResponseWriter, write func(geo *writer.GeoJSONWriter) error) {
+	w.Header().Set("Content-Type", writer.GeoJSONContentType)
+	w.WriteHeader(http.StatusOK)
+
+	geo := writer.NewGeoJSONWriter(w)
+	if err := write(geo); err != nil {
+		slog.Error("error to write geojson", "error", err)
+		return
+	}
+	if err := geo.Close(); err != nil {
+		slog.Error("error to write geojson", "error", err)
+	}
+}
+
+func writePortPage(w http.ResponseWriter, r *http.Request, page *domain.PortPage) {
+	if !wantsGeoJSON(w, r) {
+		writeResponse(w, http.StatusOK, page, nil)
+		return
+	}
+
+	writeGeoJSON(w, func(geo *writer.GeoJSONWriter) error {
+		for _, port := range page.Ports {
+			if err := geo.Write(port, nil); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor != "" {
+			geo.AddMember("next_cursor", page.NextCursor)
+		}
+		return nil
+	})
+}
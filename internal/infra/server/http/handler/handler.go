@@ -3,28 +3,63 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/writer"
 )
 
 type HTTPHandler struct {
 	portService domain.ServicePort
+	importJobs  domain.ImportJobPort
 	mux         *http.ServeMux
 }
 
-func NewHTTPHandler(portService domain.ServicePort) *HTTPHandler {
-	h := &HTTPHandler{portService: portService}
+func NewHTTPHandler(portService domain.ServicePort, importJobs domain.ImportJobPort) *HTTPHandler {
+	h := &HTTPHandler{portService: portService, importJobs: importJobs}
 	h.mux = http.NewServeMux()
 
+	h.mux.HandleFunc("GET /ports", h.listPorts)
+	h.mux.HandleFunc("GET /ports/nearby", h.getNearbyPorts)
+	h.mux.HandleFunc("GET /ports/search", h.searchPorts)
 	h.mux.HandleFunc("GET /ports/{id}", h.getPort)
+	// "GET /ports/by-code/{code}" and "GET /ports/{id}/history" overlap for the mux, they share one pattern
+	h.mux.HandleFunc("GET /ports/{id}/{subresource}", h.getPortSubresource)
 	h.mux.HandleFunc("POST /ports", h.createPort)
+	h.mux.HandleFunc("PATCH /ports/{id}", h.patchPort)
+	h.mux.HandleFunc("DELETE /ports/{id}", h.deletePort)
+	// "{id}:restore" is not a valid wildcard, the action suffix is parsed by the handler
+	h.mux.HandleFunc("POST /ports/{action}", h.portAction)
+	h.mux.HandleFunc("GET /countries", h.listCountries)
+	h.mux.HandleFunc("GET /countries/{code}/ports", h.listCountryPorts)
+	h.mux.HandleFunc("GET /imports", h.listImports)
+	h.mux.HandleFunc("POST /imports", h.startImport)
+	h.mux.HandleFunc("GET /imports/{id}", h.getImport)
+	h.mux.HandleFunc("DELETE /imports/{id}", h.cancelImport)
 
 	return h
 }
 
+// actorHeader optionally names the person or system behind a request, it is recorded in the port history.
+const actorHeader = "X-Actor"
+
 func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	h.mux.ServeHTTP(w, r)
+	actor := r.Header.Get(actorHeader)
+	if actor == "" {
+		actor = r.RemoteAddr
+	}
+	ctx := domain.WithChangeSource(r.Context(), domain.ChangeSource{Kind: domain.ChangeSourceHTTP, Actor: actor})
+
+	h.mux.ServeHTTP(w, r.WithContext(ctx))
 }
 
 func (h *HTTPHandler) createPort(w http.ResponseWriter, r *http.Request) {
@@ -34,14 +69,43 @@ func (h *HTTPHandler) createPort(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(p.Unlocs) == 0 {
-		writeResponse(w, http.StatusBadRequest, nil, invalidRequest)
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	if version != 0 {
+		newVersion, err := h.portService.UpdateIfMatch(r.Context(), p, version)
+		if err != nil {
+			var validationErr *domain.ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				writeValidationError(w, validationErr)
+			case errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrPortNotFound):
+				writeResponse(w, http.StatusPreconditionFailed, nil, domain.ErrVersionConflict)
+			default:
+				writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+			}
+			return
+		}
+
+		setETag(w, newVersion)
+		writeResponse(w, http.StatusOK, nil, nil)
 		return
 	}
 
-	err := h.portService.CreateOrUpdate(r.Context(), p)
+	err = h.portService.CreateOrUpdate(r.Context(), p)
 	if err != nil {
-		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		var validationErr *domain.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			writeValidationError(w, validationErr)
+		case errors.Is(err, domain.ErrPortDeleted):
+			writeResponse(w, http.StatusConflict, nil, err)
+		default:
+			writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		}
 		return
 	}
 
@@ -55,7 +119,7 @@ func (h *HTTPHandler) getPort(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	port, err := h.portService.FindByID(r.Context(), id)
+	port, err := h.portService.Resolve(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrPortNotFound) {
 			writeResponse(w, http.StatusNotFound, nil, err)
@@ -65,9 +129,440 @@ func (h *HTTPHandler) getPort(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// secondary UN/LOCODEs answer with the canonical resource, aliases with a temporary redirect as they can be
+	// given to another port later
+	if !strings.EqualFold(*port.ID, id) {
+		status := http.StatusFound
+		if slices.Contains(port.Unlocs, strings.ToUpper(id)) {
+			status = http.StatusMovedPermanently
+		}
+		redirectToPort(w, r, *port.ID, status)
+		return
+	}
+
+	setETag(w, port.Version)
+	if wantsGeoJSON(w, r) {
+		w.Header().Set("Content-Type", writer.GeoJSONContentType)
+		w.WriteHeader(http.StatusOK)
+		if err := writer.WriteFeature(w, *port); err != nil {
+			slog.Error("error to write geojson", "error", err)
+		}
+		return
+	}
 	writeResponse(w, http.StatusOK, port, nil)
 }
 
+func (h *HTTPHandler) getPortSubresource(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.PathValue("id") == "by-code":
+		h.getPortsByCode(w, r, r.PathValue("subresource"))
+	case r.PathValue("subresource") == "history":
+		h.getPortHistory(w, r)
+	default:
+		writeResponse(w, http.StatusNotFound, nil, notFound)
+	}
+}
+
+func (h *HTTPHandler) getPortsByCode(w http.ResponseWriter, r *http.Request, code string) {
+	if code == "" {
+		writeResponse(w, http.StatusBadRequest, nil, missingCodeParameter)
+		return
+	}
+
+	ports, err := h.portService.FindByCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, domain.ErrPortNotFound) {
+			writeResponse(w, http.StatusNotFound, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	// a code can be given to another port later, the redirect must not be cached as permanent
+	if len(ports) == 1 {
+		redirectToPort(w, r, *ports[0].ID, http.StatusFound)
+		return
+	}
+
+	// the code is shared by several ports, let the client choose
+	writeResponse(w, http.StatusMultipleChoices, ports, nil)
+}
+
+func redirectToPort(w http.ResponseWriter, r *http.Request, id string, status int) {
+	http.Redirect(w, r, "/ports/"+url.PathEscape(id), status)
+}
+
+func (h *HTTPHandler) patchPort(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeResponse(w, http.StatusBadRequest, nil, missingIDParameter)
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeResponse(w, http.StatusUnsupportedMediaType, nil, unsupportedPatchType)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, invalidRequest)
+		return
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	patch, err := newPatch(mediaType, body)
+	if err != nil {
+		if errors.Is(err, unsupportedPatchType) {
+			writeResponse(w, http.StatusUnsupportedMediaType, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	port, err := h.portService.Patch(r.Context(), id, patch, version)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			writeValidationError(w, validationErr)
+		case errors.Is(err, domain.ErrInvalidPatch):
+			writeResponse(w, http.StatusUnprocessableEntity, nil, err)
+		default:
+			writeConditionalWriteError(w, err, version)
+		}
+		return
+	}
+
+	setETag(w, port.Version)
+	if wantsGeoJSON(w, r) {
+		w.Header().Set("Content-Type", writer.GeoJSONContentType)
+		w.WriteHeader(http.StatusOK)
+		if err := writer.WriteFeature(w, *port); err != nil {
+			slog.Error("error to write geojson", "error", err)
+		}
+		return
+	}
+	writeResponse(w, http.StatusOK, port, nil)
+}
+
+func (h *HTTPHandler) deletePort(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeResponse(w, http.StatusBadRequest, nil, missingIDParameter)
+		return
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	if err := h.portService.Delete(r.Context(), id, version); err != nil {
+		writeConditionalWriteError(w, err, version)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, nil, nil)
+}
+
+func (h *HTTPHandler) portAction(w http.ResponseWriter, r *http.Request) {
+	id, action, found := strings.Cut(r.PathValue("action"), ":")
+	if !found || action != "restore" {
+		writeResponse(w, http.StatusNotFound, nil, unknownAction)
+		return
+	}
+	if id == "" {
+		writeResponse(w, http.StatusBadRequest, nil, missingIDParameter)
+		return
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	if err := h.portService.Restore(r.Context(), id, version); err != nil {
+		writeConditionalWriteError(w, err, version)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, nil, nil)
+}
+
+func (h *HTTPHandler) getPortHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeResponse(w, http.StatusBadRequest, nil, missingIDParameter)
+		return
+	}
+
+	changes, err := h.portService.History(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPortNotFound) {
+			writeResponse(w, http.StatusNotFound, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, changes, nil)
+}
+
+func (h *HTTPHandler) listPorts(w http.ResponseWriter, r *http.Request) {
+	query, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+	query.CountryCode = r.URL.Query().Get("country_code")
+
+	page, err := h.portService.List(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) || errors.Is(err, domain.ErrCountryNotFound) {
+			writeResponse(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	writePortPage(w, r, page)
+}
+
+func (h *HTTPHandler) listCountries(w http.ResponseWriter, r *http.Request) {
+	countries, err := h.portService.Countries(r.Context())
+	if err != nil {
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, countries, nil)
+}
+
+// listCountryPorts is the port listing of one country, it takes the same parameters as GET /ports.
+func (h *HTTPHandler) listCountryPorts(w http.ResponseWriter, r *http.Request) {
+	query, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+	query.CountryCode = r.PathValue("code")
+
+	page, err := h.portService.List(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrCountryNotFound) {
+			writeResponse(w, http.StatusNotFound, nil, err)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			writeResponse(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	writePortPage(w, r, page)
+}
+
+func (h *HTTPHandler) getNearbyPorts(w http.ResponseWriter, r *http.Request) {
+	query, err := parseNearbyQuery(r)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	ports, err := h.portService.FindNearby(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			writeResponse(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	if wantsGeoJSON(w, r) {
+		writeGeoJSON(w, func(geo *writer.GeoJSONWriter) error {
+			for _, port := range ports {
+				if err := geo.Write(port.Port, map[string]interface{}{"distance_km": port.DistanceKm}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return
+	}
+	writeResponse(w, http.StatusOK, ports, nil)
+}
+
+func (h *HTTPHandler) searchPorts(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, err := parseLimit(values)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	results, err := h.portService.Search(r.Context(), domain.SearchQuery{Term: values.Get("q"), Limit: limit})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			writeResponse(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	if wantsGeoJSON(w, r) {
+		writeGeoJSON(w, func(geo *writer.GeoJSONWriter) error {
+			for _, result := range results {
+				if err := geo.Write(result.Port, map[string]interface{}{"score": result.Score}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return
+	}
+	writeResponse(w, http.StatusOK, results, nil)
+}
+
+func parseNearbyQuery(r *http.Request) (domain.NearbyQuery, error) {
+	var query domain.NearbyQuery
+	var err error
+
+	values := r.URL.Query()
+	if query.Latitude, err = parseFloatParam(values.Get("lat"), "lat"); err != nil {
+		return query, err
+	}
+	if query.Longitude, err = parseFloatParam(values.Get("lon"), "lon"); err != nil {
+		return query, err
+	}
+	if query.RadiusKm, err = parseFloatParam(values.Get("radius_km"), "radius_km"); err != nil {
+		return query, err
+	}
+	if query.Limit, err = parseLimit(values); err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+func parseListQuery(values url.Values) (domain.ListQuery, error) {
+	limit, err := parseLimit(values)
+	if err != nil {
+		return domain.ListQuery{}, err
+	}
+
+	return domain.ListQuery{
+		Country:  values.Get("country"),
+		Province: values.Get("province"),
+		City:     values.Get("city"),
+		Region:   values.Get("region"),
+		Timezone: values.Get("timezone"),
+		Code:     values.Get("code"),
+		Sort:     values.Get("sort"),
+		Cursor:   values.Get("cursor"),
+		Limit:    limit,
+	}, nil
+}
+
+func parseLimit(values url.Values) (int, error) {
+	value := values.Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: limit must be an integer", invalidRequest)
+	}
+
+	return limit, nil
+}
+
+func parseFloatParam(value, name string) (float64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("%w: missing %s parameter", invalidRequest, name)
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%w: %s must be a number", invalidRequest, name)
+	}
+
+	return f, nil
+}
+
+// parseIfMatch returns the version required by the If-Match header: 0 without the header,
+// domain.AnyVersion for "*". Only a single strong ETag is supported; If-Match compares ETags strongly,
+// so a weak one (W/"3") never matches and fails with domain.ErrVersionConflict.
+func parseIfMatch(r *http.Request) (int64, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		return 0, nil
+	}
+	if ifMatch == "*" {
+		return domain.AnyVersion, nil
+	}
+	if weak, found := strings.CutPrefix(ifMatch, "W/"); found {
+		if _, err := strconv.Unquote(weak); err != nil || !strings.HasPrefix(weak, `"`) {
+			return 0, invalidIfMatch
+		}
+		return 0, domain.ErrVersionConflict
+	}
+
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil || !strings.HasPrefix(ifMatch, `"`) {
+		return 0, invalidIfMatch
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, invalidIfMatch
+	}
+
+	return version, nil
+}
+
+// writeIfMatchError answers an If-Match header parseIfMatch refused.
+func writeIfMatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrVersionConflict) {
+		writeResponse(w, http.StatusPreconditionFailed, nil, err)
+		return
+	}
+	writeResponse(w, http.StatusBadRequest, nil, err)
+}
+
+// writeConditionalWriteError answers the error of a write that sent version in If-Match: with the header,
+// a missing port fails the precondition as well.
+func writeConditionalWriteError(w http.ResponseWriter, err error, version int64) {
+	switch {
+	case errors.Is(err, domain.ErrVersionConflict), version != 0 && errors.Is(err, domain.ErrPortNotFound):
+		writeResponse(w, http.StatusPreconditionFailed, nil, domain.ErrVersionConflict)
+	case errors.Is(err, domain.ErrPortNotFound):
+		writeResponse(w, http.StatusNotFound, nil, err)
+	default:
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+	}
+}
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
 func writeResponse(w http.ResponseWriter, statusCode int, data interface{}, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -87,3 +582,13 @@ func writeResponse(w http.ResponseWriter, statusCode int, data interface{}, err
 		}
 	}
 }
+
+func writeValidationError(w http.ResponseWriter, err *domain.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  domain.ErrInvalidPort.Error(),
+		"fields": err.Fields,
+	})
+}
@@ -26,7 +26,7 @@ func TestHandler(t *testing.T) {
 
 		repo := repository.NewPostgresRepository(db)
 		service := application.NewService(repo, nil)
-		h := NewHTTPHandler(service)
+		h := NewHTTPHandler(service, application.NewImportJobs(repo))
 
 		payload := `{
 		"name": "Dubai",
@@ -66,4 +66,118 @@ func TestHandler(t *testing.T) {
 		assert.Equal(t, "Dubai", responsePort.Name)
 		assert.Equal(t, "Asia/Dubai", responsePort.Timezone)
 	})
+	t.Run("create invalid port should return field errors", func(t *testing.T) {
+		ctx := context.Background()
+		container, db := suite.SetupPostgresContainer(t)
+		defer container.Terminate(ctx)
+
+		repo := repository.NewPostgresRepository(db)
+		service := application.NewService(repo, nil)
+		h := NewHTTPHandler(service, application.NewImportJobs(repo))
+
+		payload := `{
+		"name": "Dubai",
+		"coordinates": [255.27, 25.25],
+		"timezone": "Asia/Nowhere",
+		"unlocs": ["AEDXB"]
+	}`
+
+		req, err := http.NewRequest(http.MethodPost, "/ports", strings.NewReader(payload))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var response struct {
+			Error  string              `json:"error"`
+			Fields []domain.FieldError `json:"fields"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, domain.ErrInvalidPort.Error(), response.Error)
+		assert.Len(t, response.Fields, 2)
+	})
+	t.Run("patch port with merge patch", func(t *testing.T) {
+		ctx := context.Background()
+		container, db := suite.SetupPostgresContainer(t)
+		defer container.Terminate(ctx)
+
+		repo := repository.NewPostgresRepository(db)
+		service := application.NewService(repo, nil)
+		h := NewHTTPHandler(service, application.NewImportJobs(repo))
+
+		payload := `{"name": "Dubai", "timezone": "Asia/Muscat", "unlocs": ["AEDXB"], "code": "52005"}`
+		req, err := http.NewRequest(http.MethodPost, "/ports", strings.NewReader(payload))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		patchReq, err := http.NewRequest(http.MethodPatch, "/ports/AEDXB", strings.NewReader(`{"timezone": "Asia/Dubai"}`))
+		require.NoError(t, err)
+		patchReq.Header.Set("Content-Type", "application/merge-patch+json")
+		patchRR := httptest.NewRecorder()
+		h.ServeHTTP(patchRR, patchReq)
+		assert.Equal(t, http.StatusOK, patchRR.Code)
+
+		var responsePort domain.Port
+		require.NoError(t, json.NewDecoder(patchRR.Body).Decode(&responsePort))
+		assert.Equal(t, "Asia/Dubai", responsePort.Timezone)
+		assert.Equal(t, "52005", responsePort.Code)
+
+		invalidReq, err := http.NewRequest(http.MethodPatch, "/ports/AEDXB", strings.NewReader(`[{"op": "replace", "path": "/timezone", "value": "Asia/Nowhere"}]`))
+		require.NoError(t, err)
+		invalidReq.Header.Set("Content-Type", "application/json-patch+json")
+		invalidRR := httptest.NewRecorder()
+		h.ServeHTTP(invalidRR, invalidReq)
+		assert.Equal(t, http.StatusBadRequest, invalidRR.Code)
+
+		stored, err := service.FindByID(ctx, "AEDXB")
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Dubai", stored.Timezone)
+	})
+	t.Run("writes with outdated If-Match should fail with precondition failed", func(t *testing.T) {
+		ctx := context.Background()
+		container, db := suite.SetupPostgresContainer(t)
+		defer container.Terminate(ctx)
+
+		repo := repository.NewPostgresRepository(db)
+		service := application.NewService(repo, nil)
+		h := NewHTTPHandler(service, application.NewImportJobs(repo))
+
+		post := func(payload, ifMatch string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(http.MethodPost, "/ports", strings.NewReader(payload))
+			require.NoError(t, err)
+			if ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			return rr
+		}
+
+		require.Equal(t, http.StatusOK, post(`{"name": "Dubai", "unlocs": ["AEDXB"]}`, "").Code)
+
+		getReq, err := http.NewRequest(http.MethodGet, "/ports/AEDXB", nil)
+		require.NoError(t, err)
+		getRR := httptest.NewRecorder()
+		h.ServeHTTP(getRR, getReq)
+		etag := getRR.Header().Get("ETag")
+		assert.Equal(t, `"1"`, etag)
+
+		first := post(`{"name": "Dubai Port", "unlocs": ["AEDXB"]}`, etag)
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, `"2"`, first.Header().Get("ETag"))
+
+		second := post(`{"name": "Port of Dubai", "unlocs": ["AEDXB"]}`, etag)
+		assert.Equal(t, http.StatusPreconditionFailed, second.Code)
+
+		unknown := post(`{"name": "Ajman", "unlocs": ["AEAJM"]}`, `"1"`)
+		assert.Equal(t, http.StatusPreconditionFailed, unknown.Code)
+
+		stored, err := service.FindByID(ctx, "AEDXB")
+		require.NoError(t, err)
+		assert.Equal(t, "Dubai Port", stored.Name)
+	})
 }
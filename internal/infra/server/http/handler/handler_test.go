@@ -0,0 +1,306 @@
+//go:build unit
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetPortRedirects(t *testing.T) {
+	id := "CNDLC"
+
+	t.Run("get port by secondary unloc should redirect to the canonical port", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Resolve", mock.Anything, "CNDAL").Return(&domain.Port{ID: &id, Unlocs: []string{"CNDLC", "CNDAL"}}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports/CNDAL", nil))
+
+		assert.Equal(t, http.StatusMovedPermanently, rr.Code)
+		assert.Equal(t, "/ports/CNDLC", rr.Header().Get("Location"))
+	})
+
+	t.Run("get port by alias should redirect temporarily", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Resolve", mock.Anything, "Dalian").Return(&domain.Port{ID: &id, Alias: []string{"dalian"}, Unlocs: []string{"CNDLC"}}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports/Dalian", nil))
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "/ports/CNDLC", rr.Header().Get("Location"))
+	})
+
+	t.Run("get port by its id in another case should not redirect", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Resolve", mock.Anything, "cndlc").Return(&domain.Port{ID: &id, Unlocs: []string{"CNDLC"}, Version: 2}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports/cndlc", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("get port by unique code should redirect to the port", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("FindByCode", mock.Anything, "57000").Return([]domain.Port{{ID: &id}}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports/by-code/57000", nil))
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "/ports/CNDLC", rr.Header().Get("Location"))
+	})
+
+	t.Run("get port by shared code should return multiple choices", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		otherID := "CNDAL"
+		serviceMock.On("FindByCode", mock.Anything, "57000").Return([]domain.Port{{ID: &id}, {ID: &otherID}}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports/by-code/57000", nil))
+
+		assert.Equal(t, http.StatusMultipleChoices, rr.Code)
+	})
+
+	t.Run("get port by unknown code should return not found", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("FindByCode", mock.Anything, "00000").Return(nil, domain.ErrPortNotFound)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports/by-code/00000", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestListCountryPorts(t *testing.T) {
+	t.Run("list ports of a country should pass the code and the list parameters", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		query := domain.ListQuery{CountryCode: "are", Sort: "-name", Limit: 10}
+		serviceMock.On("List", mock.Anything, query).Return(&domain.PortPage{Ports: []domain.Port{}}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/countries/are/ports?sort=-name&limit=10", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("list ports of an unknown country should return not found", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("List", mock.Anything, mock.Anything).Return(nil, domain.ErrCountryNotFound)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/countries/ZZ/ports", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("list ports with an unknown country_code filter should return bad request", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("List", mock.Anything, domain.ListQuery{CountryCode: "ZZ"}).Return(nil, domain.ErrCountryNotFound)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports?country_code=ZZ", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGeoJSONResponses(t *testing.T) {
+	id := "AEAJM"
+	port := domain.Port{ID: &id, Name: "Ajman", Coordinates: []float64{55.51, 25.40}, Unlocs: []string{"AEAJM"}, Version: 3}
+
+	t.Run("list ports accepting geojson should return a feature collection", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("List", mock.Anything, domain.ListQuery{}).Return(&domain.PortPage{Ports: []domain.Port{port}, NextCursor: "next"}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/ports", nil)
+		req.Header.Set("Accept", "application/geo+json, application/json;q=0.5")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/geo+json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+		assert.JSONEq(t, `{
+			"type": "FeatureCollection",
+			"features": [{
+				"type": "Feature",
+				"id": "AEAJM",
+				"geometry": {"type": "Point", "coordinates": [55.51, 25.40]},
+				"properties": {
+					"name": "Ajman", "city": "", "country": "", "country_code": "", "alias": null, "regions": null,
+					"province": "", "subdivision_code": "", "timezone": "", "unlocs": ["AEAJM"], "code": ""
+				}
+			}],
+			"next_cursor": "next"
+		}`, rr.Body.String())
+	})
+
+	t.Run("get port accepting geojson should return a feature", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Resolve", mock.Anything, "AEAJM").Return(&port, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/ports/AEAJM", nil)
+		req.Header.Set("Accept", "application/geo+json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/geo+json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+		assert.Contains(t, rr.Body.String(), `"type":"Feature"`)
+	})
+
+	t.Run("list ports without accept should return json", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("List", mock.Anything, domain.ListQuery{}).Return(&domain.PortPage{Ports: []domain.Port{port}}, nil)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ports", nil))
+
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	})
+}
+
+func TestConditionalDelete(t *testing.T) {
+	t.Run("delete with If-Match should pass the version", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Delete", mock.Anything, "AEDXB", int64(3)).Return(nil).Once()
+
+		req := httptest.NewRequest(http.MethodDelete, "/ports/AEDXB", nil)
+		req.Header.Set("If-Match", `"3"`)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("delete and restore with an outdated If-Match should return precondition failed", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Delete", mock.Anything, "AEDXB", int64(1)).Return(domain.ErrVersionConflict).Once()
+		serviceMock.On("Restore", mock.Anything, "AEDXB", int64(1)).Return(domain.ErrPortNotFound).Once()
+
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodDelete, "/ports/AEDXB", nil),
+			httptest.NewRequest(http.MethodPost, "/ports/AEDXB:restore", nil),
+		} {
+			req.Header.Set("If-Match", `"1"`)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusPreconditionFailed, rr.Code, req.Method)
+		}
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("weak If-Match should never match", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/ports/AEDXB", nil)
+		req.Header.Set("If-Match", `W/"1"`)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		serviceMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("delete unknown port without If-Match should return not found", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Delete", mock.Anything, "XXXXX", int64(0)).Return(domain.ErrPortNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/ports/XXXXX", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestConditionalPatch(t *testing.T) {
+	t.Run("patch of unknown port with If-Match should return precondition failed", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Patch", mock.Anything, "XXXXX", mock.Anything, int64(2)).Return(nil, domain.ErrPortNotFound).Once()
+
+		req := httptest.NewRequest(http.MethodPatch, "/ports/XXXXX", strings.NewReader(`{"name":"Dubai"}`))
+		req.Header.Set("Content-Type", mergePatchContentType)
+		req.Header.Set("If-Match", `"2"`)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("patch of unknown port without If-Match should return not found", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("Patch", mock.Anything, "XXXXX", mock.Anything, int64(0)).Return(nil, domain.ErrPortNotFound).Once()
+
+		req := httptest.NewRequest(http.MethodPatch, "/ports/XXXXX", strings.NewReader(`{"name":"Dubai"}`))
+		req.Header.Set("Content-Type", mergePatchContentType)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestCreateDeletedPort(t *testing.T) {
+	t.Run("create of a deleted port should return conflict", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, nil)
+
+		serviceMock.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(domain.ErrPortDeleted).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/ports", strings.NewReader(`{"name":"Dubai","unlocs":["AEDXB"]}`)))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
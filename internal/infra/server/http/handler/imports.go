@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/internal/infra/adapters/parser"
+)
+
+// maxImportSize limits the uploaded files, as sent (compressed or not).
+const maxImportSize = 1 << 30
+
+// startImport accepts a file as the "file" part of a multipart/form-data body or as the whole body,
+// gzip, zstd and bzip2 files are decompressed. The query parameters are the options of the import command:
+// format, mode, on_error, max_errors, max_delete_percent, atomic, loader, skip_if_unchanged and unlocode_functions.
+func (h *HTTPHandler) startImport(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	format := values.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	newParser, err := importParser(format, values)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+	options, err := parseImportOptions(values)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	upload, err := spoolUpload(w, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			writeResponse(w, http.StatusRequestEntityTooLarge, nil, importTooLarge)
+		case errors.Is(err, invalidRequest), errors.Is(err, missingImportFile), errors.Is(err, importNameTooLong):
+			writeResponse(w, http.StatusBadRequest, nil, err)
+		default:
+			slog.ErrorContext(r.Context(), "error to store uploaded import", "error", err)
+			writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		}
+		return
+	}
+	release := func() {
+		_ = upload.file.Close()
+		_ = os.Remove(upload.file.Name())
+	}
+
+	reader, err := parser.Decompress(upload.file)
+	if err != nil {
+		release()
+		writeResponse(w, http.StatusBadRequest, nil, fmt.Errorf("%w: %v", invalidRequest, err))
+		return
+	}
+
+	job, err := h.importJobs.StartImportJob(r.Context(), domain.ImportJobRequest{
+		Format:   format,
+		Source:   upload.name,
+		Size:     upload.size,
+		Checksum: upload.checksum,
+		Options:  options,
+		Parser:   newParser(reader),
+		Release: func() {
+			_ = reader.Close()
+			release()
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error to start import job", "error", err)
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	w.Header().Set("Location", "/imports/"+url.PathEscape(job.ID))
+	writeResponse(w, http.StatusAccepted, job, nil)
+}
+
+// listImports returns the latest import runs, from the command line and the import jobs.
+func (h *HTTPHandler) listImports(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	runs, err := h.portService.ImportRuns(r.Context(), limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			writeResponse(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, runs, nil)
+}
+
+func (h *HTTPHandler) getImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importJobs.FindImportJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrImportJobNotFound) {
+			writeResponse(w, http.StatusNotFound, nil, err)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, job, nil)
+}
+
+// cancelImport requests the cancellation of a running job, it answers before the job is stopped.
+func (h *HTTPHandler) cancelImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importJobs.CancelImportJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrImportJobNotFound):
+			writeResponse(w, http.StatusNotFound, nil, err)
+		case errors.Is(err, domain.ErrImportJobFinished):
+			writeResponse(w, http.StatusConflict, nil, err)
+		default:
+			writeResponse(w, http.StatusInternalServerError, nil, internalServer)
+		}
+		return
+	}
+
+	writeResponse(w, http.StatusAccepted, job, nil)
+}
+
+// upload is an uploaded file stored in a temporary file, with its size and checksum as uploaded.
+type upload struct {
+	file     *os.File
+	name     string
+	size     int64
+	checksum string
+}
+
+// spoolUpload stores the uploaded file in a temporary file, the job reads it after the request is over.
+// The name is the one of the multipart file, or of the filename parameter for a raw body.
+func spoolUpload(w http.ResponseWriter, r *http.Request) (*upload, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var body io.Reader = r.Body
+	name := r.URL.Query().Get("filename")
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		parts, err := r.MultipartReader()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", invalidRequest, err)
+		}
+		for {
+			part, err := parts.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil, missingImportFile
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", invalidRequest, err)
+			}
+			if part.FormName() == "file" {
+				body, name = part, part.FileName()
+				break
+			}
+		}
+	}
+	if name == "" {
+		name = "upload"
+	}
+	if utf8.RuneCountInString(name) > domain.MaxImportSourceLength {
+		return nil, importNameTooLong
+	}
+
+	file, err := os.CreateTemp("", "ports-import-*")
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), body)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &upload{file: file, name: name, size: size, checksum: "sha256:" + hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// importParser builds the parser of the format, CSV files are read with the default columns and header detection.
+func importParser(format string, values url.Values) (func(io.Reader) domain.ParserPort, error) {
+	switch format {
+	case "json":
+		return parser.NewJSONParser, nil
+	case "ndjson":
+		return parser.NewNDJSONParser, nil
+	case "json-array":
+		return parser.NewJSONArrayParser, nil
+	case "geojson":
+		return parser.NewGeoJSONParser, nil
+	case "csv":
+		return func(reader io.Reader) domain.ParserPort {
+			return parser.NewCSVParser(reader, parser.CSVOptions{})
+		}, nil
+	case "unlocode":
+		functions := parser.UNLOCODESeaport
+		if values.Has("unlocode_functions") {
+			functions = values.Get("unlocode_functions")
+		}
+		return func(reader io.Reader) domain.ParserPort {
+			return parser.NewUNLOCODEParser(reader, functions)
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, expected json, ndjson, json-array, geojson, csv or unlocode", invalidRequest, format)
+	}
+}
+
+func parseImportOptions(values url.Values) (domain.ImportOptions, error) {
+	options := domain.ImportOptions{MaxErrors: domain.NoErrorLimit, MaxDeletePercent: domain.DefaultMaxDeletePercent}
+
+	switch values.Get("mode") {
+	case "", "upsert":
+	case "sync":
+		options.Mode = domain.ImportSync
+	default:
+		return options, fmt.Errorf("%w: mode must be upsert or sync", invalidRequest)
+	}
+
+	switch values.Get("on_error") {
+	case "", "abort":
+	case "skip":
+		options.OnError = domain.ImportSkip
+	default:
+		return options, fmt.Errorf("%w: on_error must be abort or skip", invalidRequest)
+	}
+
+	switch values.Get("loader") {
+	case "", "insert":
+	case "copy":
+		options.Loader = domain.LoadCopy
+	default:
+		return options, fmt.Errorf("%w: loader must be insert or copy", invalidRequest)
+	}
+
+	if value := values.Get("max_errors"); value != "" {
+		maxErrors, err := strconv.Atoi(value)
+		if err != nil || maxErrors < domain.NoErrorLimit {
+			return options, fmt.Errorf("%w: max_errors must be an integer of at least %d", invalidRequest, domain.NoErrorLimit)
+		}
+		options.MaxErrors = maxErrors
+	}
+	if value := values.Get("max_delete_percent"); value != "" {
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return options, fmt.Errorf("%w: max_delete_percent must be between 0 and 100", invalidRequest)
+		}
+		options.MaxDeletePercent = percent
+	}
+	if value := values.Get("atomic"); value != "" {
+		atomic, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("%w: atomic must be true or false", invalidRequest)
+		}
+		options.Atomic = atomic
+	}
+	if value := values.Get("skip_if_unchanged"); value != "" {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("%w: skip_if_unchanged must be true or false", invalidRequest)
+		}
+		options.SkipIfUnchanged = skip
+	}
+
+	return options, nil
+}
@@ -0,0 +1,216 @@
+//go:build unit
+
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/guil95/ports-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const importFile = `{"AEAJM": {"name": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]}}`
+
+// parsedIDs reads the ports the job would import.
+func parsedIDs(t *testing.T, request domain.ImportJobRequest) []string {
+	t.Helper()
+
+	portCh, errCh := request.Parser.Parse(context.Background())
+	var ids []string
+	for port := range portCh {
+		ids = append(ids, *port.ID)
+	}
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	default:
+	}
+	return ids
+}
+
+func TestImports(t *testing.T) {
+	t.Run("start import with a gzip body should start a job of the decompressed file", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		_, _ = gz.Write([]byte(importFile))
+		require.NoError(t, gz.Close())
+
+		size := int64(compressed.Len())
+		var ids []string
+		jobsMock.On("StartImportJob", mock.Anything, mock.MatchedBy(func(request domain.ImportJobRequest) bool {
+			return request.Format == "json" && request.Source == "ports.json.gz" && request.Size == size &&
+				strings.HasPrefix(request.Checksum, "sha256:") && request.Options.Mode == domain.ImportSync &&
+				request.Options.MaxErrors == 3 && request.Options.SkipIfUnchanged
+		})).Run(func(args mock.Arguments) {
+			request := args.Get(1).(domain.ImportJobRequest)
+			ids = parsedIDs(t, request)
+			request.Release()
+		}).Return(&domain.ImportJob{ID: "abc", Status: domain.ImportJobRunning}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/imports?filename=ports.json.gz&mode=sync&max_errors=3&skip_if_unchanged=true", &compressed))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, "/imports/abc", rr.Header().Get("Location"))
+		assert.Equal(t, []string{"AEAJM"}, ids)
+		jobsMock.AssertExpectations(t)
+	})
+
+	t.Run("start import with a multipart body should read the file part", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("comment", "weekly"))
+		part, err := form.CreateFormFile("file", "ports.json")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte(importFile))
+		require.NoError(t, form.Close())
+
+		var ids []string
+		jobsMock.On("StartImportJob", mock.Anything, mock.MatchedBy(func(request domain.ImportJobRequest) bool {
+			return request.Source == "ports.json"
+		})).Run(func(args mock.Arguments) {
+			request := args.Get(1).(domain.ImportJobRequest)
+			ids = parsedIDs(t, request)
+			request.Release()
+		}).Return(&domain.ImportJob{ID: "abc", Status: domain.ImportJobRunning}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, []string{"AEAJM"}, ids)
+	})
+
+	t.Run("start import without a file part should return bad request", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("comment", "weekly"))
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		jobsMock.AssertNotCalled(t, "StartImportJob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("start import with a file name too long to be recorded should return bad request", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", strings.Repeat("p", 252)+".json")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte(importFile))
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		jobsMock.AssertNotCalled(t, "StartImportJob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("start import with invalid options should return bad request", func(t *testing.T) {
+		for _, query := range []string{"format=xml", "mode=replace", "max_delete_percent=150", "atomic=maybe"} {
+			jobsMock := new(mocks.ImportJobPort)
+			h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/imports?"+query, bytes.NewBufferString(importFile)))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+			jobsMock.AssertNotCalled(t, "StartImportJob", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("list imports should return the latest runs", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, new(mocks.ImportJobPort))
+
+		serviceMock.On("ImportRuns", mock.Anything, 5).Return([]domain.ImportRun{
+			{ID: "abc", Source: "ports.json", Outcome: domain.ImportSucceeded, Summary: domain.ImportSummary{Parsed: 2, Imported: 2}},
+		}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/imports?limit=5", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"outcome":"succeeded"`)
+	})
+
+	t.Run("list imports with a limit out of range should return bad request", func(t *testing.T) {
+		serviceMock := new(mocks.ServicePort)
+		h := NewHTTPHandler(serviceMock, new(mocks.ImportJobPort))
+
+		serviceMock.On("ImportRuns", mock.Anything, 1000).Return(nil, domain.ErrInvalidQuery).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/imports?limit=1000", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("get unknown import should return not found", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		jobsMock.On("FindImportJob", mock.Anything, "abc").Return(nil, domain.ErrImportJobNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/imports/abc", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("cancel import should return the job", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		jobsMock.On("CancelImportJob", mock.Anything, "abc").Return(&domain.ImportJob{ID: "abc", Status: domain.ImportJobRunning, CancelRequested: true}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/imports/abc", nil))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"cancel_requested":true`)
+	})
+
+	t.Run("cancel finished import should return conflict", func(t *testing.T) {
+		jobsMock := new(mocks.ImportJobPort)
+		h := NewHTTPHandler(new(mocks.ServicePort), jobsMock)
+
+		jobsMock.On("CancelImportJob", mock.Anything, "abc").Return(nil, domain.ErrImportJobFinished).Once()
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/imports/abc", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
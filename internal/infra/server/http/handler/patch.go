@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/guil95/ports-service/internal/core/domain"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	maxPatchSize          = 1 << 20
+)
+
+// mergePatch is a JSON Merge Patch (RFC 7396) document.
+type mergePatch []byte
+
+func (p mergePatch) Apply(port domain.Port) (domain.Port, error) {
+	return applyToJSON(port, func(doc []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(doc, p)
+	})
+}
+
+// jsonPatch is a JSON Patch (RFC 6902) document.
+type jsonPatch struct {
+	operations jsonpatch.Patch
+}
+
+func (p jsonPatch) Apply(port domain.Port) (domain.Port, error) {
+	return applyToJSON(port, p.operations.Apply)
+}
+
+// newPatch decodes the request body according to its media type.
+func newPatch(mediaType string, body []byte) (domain.Patch, error) {
+	switch mediaType {
+	case mergePatchContentType:
+		var doc map[string]interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("%w: merge patch must be a JSON object", invalidRequest)
+		}
+		return mergePatch(body), nil
+	case jsonPatchContentType:
+		operations, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", invalidRequest, err)
+		}
+		return jsonPatch{operations: operations}, nil
+	default:
+		return nil, unsupportedPatchType
+	}
+}
+
+// applyToJSON applies a patch over the JSON representation of the port. Fields that
+// do not exist on a port are rejected instead of being silently dropped.
+func applyToJSON(port domain.Port, apply func(doc []byte) ([]byte, error)) (domain.Port, error) {
+	doc, err := json.Marshal(port)
+	if err != nil {
+		return domain.Port{}, err
+	}
+
+	patched, err := apply(doc)
+	if err != nil {
+		return domain.Port{}, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+
+	var result domain.Port
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return domain.Port{}, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+
+	return result, nil
+}
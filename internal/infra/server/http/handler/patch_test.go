@@ -0,0 +1,90 @@
+//go:build unit
+
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/guil95/ports-service/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func patchTestPort() domain.Port {
+	id := "AEDXB"
+	return domain.Port{
+		ID:          &id,
+		Name:        "Dubai",
+		City:        "Dubai",
+		Country:     "United Arab Emirates",
+		Alias:       []string{},
+		Regions:     []string{},
+		Coordinates: []float64{55.27, 25.25},
+		Province:    "Dubayy [Dubai]",
+		Timezone:    "Asia/Muscat",
+		Unlocs:      []string{"AEDXB"},
+		Code:        "52005",
+	}
+}
+
+func TestPatch(t *testing.T) {
+	t.Run("merge patch should change only the given fields", func(t *testing.T) {
+		patch, err := newPatch(mergePatchContentType, []byte(`{"timezone": "Asia/Dubai", "alias": ["Dubayy"], "code": null}`))
+		require.NoError(t, err)
+
+		patched, err := patch.Apply(patchTestPort())
+		require.NoError(t, err)
+
+		expected := patchTestPort()
+		expected.Timezone = "Asia/Dubai"
+		expected.Alias = []string{"Dubayy"}
+		expected.Code = ""
+		assert.Equal(t, expected, patched)
+	})
+
+	t.Run("json patch should apply every operation", func(t *testing.T) {
+		patch, err := newPatch(jsonPatchContentType, []byte(`[
+			{"op": "test", "path": "/timezone", "value": "Asia/Muscat"},
+			{"op": "replace", "path": "/timezone", "value": "Asia/Dubai"},
+			{"op": "add", "path": "/unlocs/-", "value": "AEDWC"},
+			{"op": "remove", "path": "/coordinates"}
+		]`))
+		require.NoError(t, err)
+
+		patched, err := patch.Apply(patchTestPort())
+		require.NoError(t, err)
+
+		assert.Equal(t, "Asia/Dubai", patched.Timezone)
+		assert.Equal(t, []string{"AEDXB", "AEDWC"}, patched.Unlocs)
+		assert.Nil(t, patched.Coordinates)
+		assert.Equal(t, "Dubai", patched.Name)
+	})
+
+	t.Run("failed json patch test should return invalid patch", func(t *testing.T) {
+		patch, err := newPatch(jsonPatchContentType, []byte(`[{"op": "test", "path": "/timezone", "value": "Asia/Dubai"}]`))
+		require.NoError(t, err)
+
+		_, err = patch.Apply(patchTestPort())
+		assert.True(t, errors.Is(err, domain.ErrInvalidPatch))
+	})
+
+	t.Run("unknown fields should return invalid patch", func(t *testing.T) {
+		patch, err := newPatch(mergePatchContentType, []byte(`{"timezon": "Asia/Dubai"}`))
+		require.NoError(t, err)
+
+		_, err = patch.Apply(patchTestPort())
+		assert.True(t, errors.Is(err, domain.ErrInvalidPatch))
+	})
+
+	t.Run("malformed documents and other media types should be rejected", func(t *testing.T) {
+		_, err := newPatch(mergePatchContentType, []byte(`["timezone"]`))
+		assert.True(t, errors.Is(err, invalidRequest))
+
+		_, err = newPatch(jsonPatchContentType, []byte(`{"op": "remove"}`))
+		assert.True(t, errors.Is(err, invalidRequest))
+
+		_, err = newPatch("application/json", []byte(`{}`))
+		assert.True(t, errors.Is(err, unsupportedPatchType))
+	})
+}
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_ports_code_id;
+DROP INDEX IF EXISTS idx_ports_country_id;
+DROP INDEX IF EXISTS idx_ports_city_id;
+DROP INDEX IF EXISTS idx_ports_name_id;
+DROP INDEX IF EXISTS idx_ports_regions;
+DROP INDEX IF EXISTS idx_ports_code;
+DROP INDEX IF EXISTS idx_ports_timezone;
+DROP INDEX IF EXISTS idx_ports_city;
+DROP INDEX IF EXISTS idx_ports_province;
+DROP INDEX IF EXISTS idx_ports_country;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE INDEX IF NOT EXISTS idx_ports_country ON ports (LOWER(country));
+CREATE INDEX IF NOT EXISTS idx_ports_province ON ports (LOWER(province));
+CREATE INDEX IF NOT EXISTS idx_ports_city ON ports (LOWER(city));
+CREATE INDEX IF NOT EXISTS idx_ports_timezone ON ports (LOWER(timezone));
+CREATE INDEX IF NOT EXISTS idx_ports_code ON ports (code);
+CREATE INDEX IF NOT EXISTS idx_ports_regions ON ports USING GIN (regions);
+
+-- keyset pagination indexes, one per sortable column
+CREATE INDEX IF NOT EXISTS idx_ports_name_id ON ports (COALESCE(name, ''), id);
+CREATE INDEX IF NOT EXISTS idx_ports_city_id ON ports (COALESCE(city, ''), id);
+CREATE INDEX IF NOT EXISTS idx_ports_country_id ON ports (COALESCE(country, ''), id);
+CREATE INDEX IF NOT EXISTS idx_ports_code_id ON ports (COALESCE(code, ''), id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_ports_search_text_trgm;
+ALTER TABLE ports
+    DROP COLUMN IF EXISTS search_text,
+    DROP COLUMN IF EXISTS search_name;
+DROP FUNCTION IF EXISTS ports_search_document(TEXT, TEXT, TEXT, TEXT[]);
+DROP FUNCTION IF EXISTS ports_search_normalize(TEXT);
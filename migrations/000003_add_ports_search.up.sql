@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Lower-cases, strips accents and stray spacing diacritics (e.g. "Abu Z¸aby" -> "abu zaby")
+-- and collapses punctuation into single spaces.
+CREATE OR REPLACE FUNCTION ports_search_normalize(value TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS
+$$
+SELECT btrim(regexp_replace(
+    lower(public.unaccent('public.unaccent'::regdictionary, translate(value, '¸˛˘˙˚˝`´¨ˆ˜', ''))),
+    '[^[:alnum:]]+', ' ', 'g'))
+$$;
+
+CREATE OR REPLACE FUNCTION ports_search_document(name TEXT, city TEXT, province TEXT, alias TEXT[]) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE AS
+$$
+SELECT ports_search_normalize(concat_ws(' ', name, city, province, array_to_string(alias, ' ')))
+$$;
+
+ALTER TABLE ports
+    ADD COLUMN IF NOT EXISTS search_name TEXT GENERATED ALWAYS AS (ports_search_normalize(COALESCE(name, ''))) STORED,
+    ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (ports_search_document(name, city, province, alias)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_ports_search_text_trgm ON ports USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_ports_history ON ports;
+DROP FUNCTION IF EXISTS ports_record_history();
+DROP TABLE IF EXISTS port_history;
@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS port_history (
+    id         BIGSERIAL PRIMARY KEY,
+    port_id    VARCHAR(50) NOT NULL,
+    operation  VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    source     VARCHAR(20) NOT NULL,
+    actor      VARCHAR(255),
+    run_id     VARCHAR(64),
+    previous   JSONB,
+    diff       JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_port_history_port_id ON port_history (port_id, changed_at DESC, id DESC);
+
+-- Records every write on ports with the previous version of the row and a field-level diff
+-- ({"field": {"old": ..., "new": ...}}). The writer identifies itself with the transaction-local
+-- settings ports.change_source, ports.change_actor and ports.change_run_id.
+CREATE OR REPLACE FUNCTION ports_record_history() RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    old_doc JSONB;
+    new_doc JSONB;
+    changes JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_doc := to_jsonb(OLD) - ARRAY ['search_name', 'search_text'];
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_doc := to_jsonb(NEW) - ARRAY ['search_name', 'search_text'];
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(k.key, jsonb_build_object('old', old_doc -> k.key, 'new', new_doc -> k.key)), '{}'::JSONB)
+    INTO changes
+    FROM jsonb_object_keys(COALESCE(old_doc, '{}'::JSONB) || COALESCE(new_doc, '{}'::JSONB)) AS k(key)
+    WHERE (old_doc -> k.key) IS DISTINCT FROM (new_doc -> k.key);
+
+    IF TG_OP = 'UPDATE' AND changes = '{}'::JSONB THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO port_history (port_id, operation, source, actor, run_id, previous, diff)
+    VALUES (COALESCE(new_doc ->> 'id', old_doc ->> 'id'),
+            lower(TG_OP),
+            COALESCE(NULLIF(current_setting('ports.change_source', true), ''), 'unknown'),
+            NULLIF(current_setting('ports.change_actor', true), ''),
+            NULLIF(current_setting('ports.change_run_id', true), ''),
+            old_doc,
+            changes);
+
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS trg_ports_history ON ports;
+CREATE TRIGGER trg_ports_history
+    AFTER INSERT OR UPDATE OR DELETE
+    ON ports
+    FOR EACH ROW
+EXECUTE FUNCTION ports_record_history();
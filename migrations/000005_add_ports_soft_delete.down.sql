@@ -0,0 +1,40 @@
+-- Restores the history function of 000004.
+CREATE OR REPLACE FUNCTION ports_record_history() RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    old_doc JSONB;
+    new_doc JSONB;
+    changes JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_doc := to_jsonb(OLD) - ARRAY ['search_name', 'search_text'];
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_doc := to_jsonb(NEW) - ARRAY ['search_name', 'search_text'];
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(k.key, jsonb_build_object('old', old_doc -> k.key, 'new', new_doc -> k.key)), '{}'::JSONB)
+    INTO changes
+    FROM jsonb_object_keys(COALESCE(old_doc, '{}'::JSONB) || COALESCE(new_doc, '{}'::JSONB)) AS k(key)
+    WHERE (old_doc -> k.key) IS DISTINCT FROM (new_doc -> k.key);
+
+    IF TG_OP = 'UPDATE' AND changes = '{}'::JSONB THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO port_history (port_id, operation, source, actor, run_id, previous, diff)
+    VALUES (COALESCE(new_doc ->> 'id', old_doc ->> 'id'),
+            lower(TG_OP),
+            COALESCE(NULLIF(current_setting('ports.change_source', true), ''), 'unknown'),
+            NULLIF(current_setting('ports.change_actor', true), ''),
+            NULLIF(current_setting('ports.change_run_id', true), ''),
+            old_doc,
+            changes);
+
+    RETURN NULL;
+END;
+$$;
+
+DROP INDEX IF EXISTS idx_ports_deleted_at;
+ALTER TABLE ports DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_ports_version ON ports;
+DROP FUNCTION IF EXISTS ports_bump_version();
+ALTER TABLE ports DROP COLUMN IF EXISTS version;
@@ -0,0 +1,24 @@
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- The version only moves when the row really changes, whatever the write path.
+CREATE OR REPLACE FUNCTION ports_bump_version() RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    IF (to_jsonb(NEW) - ARRAY ['version', 'search_name', 'search_text'])
+        IS DISTINCT FROM (to_jsonb(OLD) - ARRAY ['version', 'search_name', 'search_text']) THEN
+        NEW.version := OLD.version + 1;
+    ELSE
+        NEW.version := OLD.version;
+    END IF;
+
+    RETURN NEW;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS trg_ports_version ON ports;
+CREATE TRIGGER trg_ports_version
+    BEFORE UPDATE
+    ON ports
+    FOR EACH ROW
+EXECUTE FUNCTION ports_bump_version();
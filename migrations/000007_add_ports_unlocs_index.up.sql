@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_ports_unlocs ON ports USING GIN (unlocs);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_ports_country_code;
+
+ALTER TABLE ports DROP COLUMN IF EXISTS subdivision_code;
+ALTER TABLE ports DROP COLUMN IF EXISTS country_code;
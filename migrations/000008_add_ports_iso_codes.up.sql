@@ -0,0 +1,5 @@
+-- ISO 3166 codes resolved by the service from country and province; existing rows get them on their next write.
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS country_code CHAR(2);
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS subdivision_code VARCHAR(6);
+
+CREATE INDEX IF NOT EXISTS idx_ports_country_code ON ports (country_code);
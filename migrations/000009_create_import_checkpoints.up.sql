@@ -0,0 +1,10 @@
+-- Progress of the imports, one row per imported file (its SHA-256), so an interrupted import can resume.
+-- byte_offset and last_key locate the last stored record, in the decompressed file.
+CREATE TABLE IF NOT EXISTS import_checkpoints (
+    fingerprint VARCHAR(80) PRIMARY KEY,
+    source      VARCHAR(255) NOT NULL,
+    last_key    VARCHAR(255) NOT NULL,
+    byte_offset BIGINT NOT NULL,
+    imported    BIGINT NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
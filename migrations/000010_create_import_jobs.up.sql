@@ -0,0 +1,17 @@
+-- Imports started over HTTP, run in the background by the server. The counters are updated while the job runs,
+-- cancel_requested asks the server running the job to stop it.
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id               VARCHAR(32) PRIMARY KEY,
+    status           VARCHAR(20) NOT NULL,
+    format           VARCHAR(20) NOT NULL,
+    source           VARCHAR(255) NOT NULL,
+    imported         BIGINT NOT NULL DEFAULT 0,
+    rejected         BIGINT NOT NULL DEFAULT 0,
+    skipped          BIGINT NOT NULL DEFAULT 0,
+    deleted          BIGINT NOT NULL DEFAULT 0,
+    error            TEXT NOT NULL DEFAULT '',
+    cancel_requested BOOLEAN NOT NULL DEFAULT false,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at      TIMESTAMPTZ
+);
@@ -0,0 +1,22 @@
+-- History of the import runs, from the command line and the HTTP import jobs (a job and its run share the ID).
+-- size and checksum are the ones of the file as read, NULL for the standard input.
+CREATE TABLE IF NOT EXISTS import_runs (
+    id          VARCHAR(32) PRIMARY KEY,
+    source      VARCHAR(255) NOT NULL,
+    format      VARCHAR(20) NOT NULL,
+    mode        VARCHAR(20) NOT NULL,
+    dry_run     BOOLEAN NOT NULL DEFAULT false,
+    size        BIGINT,
+    checksum    VARCHAR(71),
+    outcome     VARCHAR(20) NOT NULL,
+    error       TEXT NOT NULL DEFAULT '',
+    parsed      BIGINT NOT NULL DEFAULT 0,
+    written     BIGINT NOT NULL DEFAULT 0,
+    rejected    BIGINT NOT NULL DEFAULT 0,
+    skipped     BIGINT NOT NULL DEFAULT 0,
+    deleted     BIGINT NOT NULL DEFAULT 0,
+    started_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS import_runs_started_at_idx ON import_runs (started_at DESC);
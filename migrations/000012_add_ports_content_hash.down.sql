@@ -0,0 +1,65 @@
+-- Restores the functions of 000006 and 000005.
+CREATE OR REPLACE FUNCTION ports_bump_version() RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    IF (to_jsonb(NEW) - ARRAY ['version', 'search_name', 'search_text'])
+        IS DISTINCT FROM (to_jsonb(OLD) - ARRAY ['version', 'search_name', 'search_text']) THEN
+        NEW.version := OLD.version + 1;
+    ELSE
+        NEW.version := OLD.version;
+    END IF;
+
+    RETURN NEW;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION ports_record_history() RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    old_doc JSONB;
+    new_doc JSONB;
+    changes JSONB;
+    op      TEXT := lower(TG_OP);
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_doc := to_jsonb(OLD) - ARRAY ['search_name', 'search_text'];
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_doc := to_jsonb(NEW) - ARRAY ['search_name', 'search_text'];
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(k.key, jsonb_build_object('old', old_doc -> k.key, 'new', new_doc -> k.key)), '{}'::JSONB)
+    INTO changes
+    FROM jsonb_object_keys(COALESCE(old_doc, '{}'::JSONB) || COALESCE(new_doc, '{}'::JSONB)) AS k(key)
+    WHERE (old_doc -> k.key) IS DISTINCT FROM (new_doc -> k.key);
+
+    IF TG_OP = 'UPDATE' AND changes = '{}'::JSONB THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'UPDATE' AND old_doc ->> 'deleted_at' IS NULL AND new_doc ->> 'deleted_at' IS NOT NULL THEN
+        op := 'delete';
+    ELSIF TG_OP = 'UPDATE' AND old_doc ->> 'deleted_at' IS NOT NULL AND new_doc ->> 'deleted_at' IS NULL THEN
+        op := 'restore';
+    ELSIF TG_OP = 'DELETE' THEN
+        op := 'purge';
+    END IF;
+
+    INSERT INTO port_history (port_id, operation, source, actor, run_id, previous, diff)
+    VALUES (COALESCE(new_doc ->> 'id', old_doc ->> 'id'),
+            op,
+            COALESCE(NULLIF(current_setting('ports.change_source', true), ''), 'unknown'),
+            NULLIF(current_setting('ports.change_actor', true), ''),
+            NULLIF(current_setting('ports.change_run_id', true), ''),
+            old_doc,
+            changes);
+
+    RETURN NULL;
+END;
+$$;
+
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS created, DROP COLUMN IF EXISTS updated, DROP COLUMN IF EXISTS unchanged;
+ALTER TABLE import_runs DROP COLUMN IF EXISTS created, DROP COLUMN IF EXISTS updated, DROP COLUMN IF EXISTS unchanged;
+ALTER TABLE ports DROP COLUMN IF EXISTS content_hash;
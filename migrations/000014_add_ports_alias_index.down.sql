@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ports_alias_lower;
+DROP FUNCTION IF EXISTS lower_array(TEXT[]);
@@ -0,0 +1,8 @@
+-- Lookups match the aliases case-insensitively, the index needs the lower-cased array from an immutable function.
+CREATE OR REPLACE FUNCTION lower_array(TEXT[]) RETURNS TEXT[]
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE AS
+$$
+SELECT array_agg(lower(value)) FROM unnest($1) AS value
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_ports_alias_lower ON ports USING GIN (lower_array(alias));
@@ -7,6 +7,8 @@ import (
 
 	domain "github.com/guil95/ports-service/internal/core/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // RepositoryPort is an autogenerated mock type for the RepositoryPort type
@@ -14,6 +16,270 @@ type RepositoryPort struct {
 	mock.Mock
 }
 
+// CopyBulk provides a mock function with given fields: ctx, ports
+func (_m *RepositoryPort) CopyBulk(ctx context.Context, ports []domain.Port) (domain.SaveResult, error) {
+	ret := _m.Called(ctx, ports)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CopyBulk")
+	}
+
+	var r0 domain.SaveResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Port) (domain.SaveResult, error)); ok {
+		return rf(ctx, ports)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Port) domain.SaveResult); ok {
+		r0 = rf(ctx, ports)
+	} else {
+		r0 = ret.Get(0).(domain.SaveResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.Port) error); ok {
+		r1 = rf(ctx, ports)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Count provides a mock function with given fields: ctx
+func (_m *RepositoryPort) Count(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Count")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountByCountry provides a mock function with given fields: ctx
+func (_m *RepositoryPort) CountByCountry(ctx context.Context) (map[string]int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByCountry")
+	}
+
+	var r0 map[string]int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[string]int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int64); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateImportJob provides a mock function with given fields: ctx, job
+func (_m *RepositoryPort) CreateImportJob(ctx context.Context, job domain.ImportJob) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateImportJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateImportRun provides a mock function with given fields: ctx, run
+func (_m *RepositoryPort) CreateImportRun(ctx context.Context, run domain.ImportRun) error {
+	ret := _m.Called(ctx, run)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateImportRun")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportRun) error); ok {
+		r0 = rf(ctx, run)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *RepositoryPort) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteCheckpoint provides a mock function with given fields: ctx, fingerprint
+func (_m *RepositoryPort) DeleteCheckpoint(ctx context.Context, fingerprint string) error {
+	ret := _m.Called(ctx, fingerprint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCheckpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fingerprint)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteIfVersion provides a mock function with given fields: ctx, id, version
+func (_m *RepositoryPort) DeleteIfVersion(ctx context.Context, id string, version int64) error {
+	ret := _m.Called(ctx, id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteIfVersion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteMany provides a mock function with given fields: ctx, ids
+func (_m *RepositoryPort) DeleteMany(ctx context.Context, ids []string) (int64, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMany")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (int64, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) int64); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeletedIDs provides a mock function with given fields: ctx, ids
+func (_m *RepositoryPort) DeletedIDs(ctx context.Context, ids []string) ([]string, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletedIDs")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByCode provides a mock function with given fields: ctx, code
+func (_m *RepositoryPort) FindByCode(ctx context.Context, code string) ([]domain.Port, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByCode")
+	}
+
+	var r0 []domain.Port
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.Port, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.Port); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Port)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByID provides a mock function with given fields: ctx, id
 func (_m *RepositoryPort) FindByID(ctx context.Context, id string) (*domain.Port, error) {
 	ret := _m.Called(ctx, id)
@@ -44,22 +310,600 @@ func (_m *RepositoryPort) FindByID(ctx context.Context, id string) (*domain.Port
 	return r0, r1
 }
 
-// SaveBulk provides a mock function with given fields: ctx, port
-func (_m *RepositoryPort) SaveBulk(ctx context.Context, port []domain.Port) error {
-	ret := _m.Called(ctx, port)
+// FindByIDs provides a mock function with given fields: ctx, ids
+func (_m *RepositoryPort) FindByIDs(ctx context.Context, ids []string) (map[string]domain.Port, error) {
+	ret := _m.Called(ctx, ids)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveBulk")
+		panic("no return value specified for FindByIDs")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, []domain.Port) error); ok {
-		r0 = rf(ctx, port)
+	var r0 map[string]domain.Port
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (map[string]domain.Port, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) map[string]domain.Port); ok {
+		r0 = rf(ctx, ids)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]domain.Port)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByIdentifier provides a mock function with given fields: ctx, identifier
+func (_m *RepositoryPort) FindByIdentifier(ctx context.Context, identifier string) (*domain.Port, error) {
+	ret := _m.Called(ctx, identifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByIdentifier")
+	}
+
+	var r0 *domain.Port
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Port, error)); ok {
+		return rf(ctx, identifier)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Port); ok {
+		r0 = rf(ctx, identifier)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Port)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, identifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindCheckpoint provides a mock function with given fields: ctx, fingerprint
+func (_m *RepositoryPort) FindCheckpoint(ctx context.Context, fingerprint string) (*domain.Checkpoint, error) {
+	ret := _m.Called(ctx, fingerprint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindCheckpoint")
+	}
+
+	var r0 *domain.Checkpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Checkpoint, error)); ok {
+		return rf(ctx, fingerprint)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Checkpoint); ok {
+		r0 = rf(ctx, fingerprint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Checkpoint)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, fingerprint)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindImportJob provides a mock function with given fields: ctx, id
+func (_m *RepositoryPort) FindImportJob(ctx context.Context, id string) (*domain.ImportJob, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindImportJob")
+	}
+
+	var r0 *domain.ImportJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.ImportJob, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.ImportJob); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ImportJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindLastImportRun provides a mock function with given fields: ctx
+func (_m *RepositoryPort) FindLastImportRun(ctx context.Context) (*domain.ImportRun, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindLastImportRun")
+	}
+
+	var r0 *domain.ImportRun
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*domain.ImportRun, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.ImportRun); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ImportRun)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindNearby provides a mock function with given fields: ctx, query
+func (_m *RepositoryPort) FindNearby(ctx context.Context, query domain.NearbyQuery) ([]domain.NearbyPort, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindNearby")
+	}
+
+	var r0 []domain.NearbyPort
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NearbyQuery) ([]domain.NearbyPort, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NearbyQuery) []domain.NearbyPort); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NearbyPort)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NearbyQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FinishImportJob provides a mock function with given fields: ctx, job
+func (_m *RepositoryPort) FinishImportJob(ctx context.Context, job domain.ImportJob) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishImportJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishImportRun provides a mock function with given fields: ctx, run
+func (_m *RepositoryPort) FinishImportRun(ctx context.Context, run domain.ImportRun) error {
+	ret := _m.Called(ctx, run)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishImportRun")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportRun) error); ok {
+		r0 = rf(ctx, run)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishStaleImportJobs provides a mock function with given fields: ctx, staleSince, reason
+func (_m *RepositoryPort) FinishStaleImportJobs(ctx context.Context, staleSince time.Time, reason string) (int64, error) {
+	ret := _m.Called(ctx, staleSince, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishStaleImportJobs")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, string) (int64, error)); ok {
+		return rf(ctx, staleSince, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, string) int64); ok {
+		r0 = rf(ctx, staleSince, reason)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, string) error); ok {
+		r1 = rf(ctx, staleSince, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// History provides a mock function with given fields: ctx, portID
+func (_m *RepositoryPort) History(ctx context.Context, portID string) ([]domain.PortChange, error) {
+	ret := _m.Called(ctx, portID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for History")
+	}
+
+	var r0 []domain.PortChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.PortChange, error)); ok {
+		return rf(ctx, portID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.PortChange); ok {
+		r0 = rf(ctx, portID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PortChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, portID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InTransaction provides a mock function with given fields: ctx, fn
+func (_m *RepositoryPort) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InTransaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(ctx context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields: ctx, query
+func (_m *RepositoryPort) List(ctx context.Context, query domain.ListQuery) (*domain.PortPage, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 *domain.PortPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ListQuery) (*domain.PortPage, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ListQuery) *domain.PortPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PortPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ListQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListImportRuns provides a mock function with given fields: ctx, limit
+func (_m *RepositoryPort) ListImportRuns(ctx context.Context, limit int) ([]domain.ImportRun, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListImportRuns")
+	}
+
+	var r0 []domain.ImportRun
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]domain.ImportRun, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []domain.ImportRun); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ImportRun)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MissingIDs provides a mock function with given fields: ctx, ids
+func (_m *RepositoryPort) MissingIDs(ctx context.Context, ids []string) ([]string, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MissingIDs")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, deletedBefore
+func (_m *RepositoryPort) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Purge")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, deletedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, deletedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequestImportJobCancel provides a mock function with given fields: ctx, id
+func (_m *RepositoryPort) RequestImportJobCancel(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestImportJobCancel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *RepositoryPort) Restore(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreIfVersion provides a mock function with given fields: ctx, id, version
+func (_m *RepositoryPort) RestoreIfVersion(ctx context.Context, id string, version int64) error {
+	ret := _m.Called(ctx, id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreIfVersion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveBulk provides a mock function with given fields: ctx, port
+func (_m *RepositoryPort) SaveBulk(ctx context.Context, port []domain.Port) (domain.SaveResult, error) {
+	ret := _m.Called(ctx, port)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveBulk")
+	}
+
+	var r0 domain.SaveResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Port) (domain.SaveResult, error)); ok {
+		return rf(ctx, port)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Port) domain.SaveResult); ok {
+		r0 = rf(ctx, port)
+	} else {
+		r0 = ret.Get(0).(domain.SaveResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.Port) error); ok {
+		r1 = rf(ctx, port)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveCheckpoint provides a mock function with given fields: ctx, checkpoint
+func (_m *RepositoryPort) SaveCheckpoint(ctx context.Context, checkpoint domain.Checkpoint) error {
+	ret := _m.Called(ctx, checkpoint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveCheckpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Checkpoint) error); ok {
+		r0 = rf(ctx, checkpoint)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveIfVersion provides a mock function with given fields: ctx, port, version
+func (_m *RepositoryPort) SaveIfVersion(ctx context.Context, port domain.Port, version int64) (int64, error) {
+	ret := _m.Called(ctx, port, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveIfVersion")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Port, int64) (int64, error)); ok {
+		return rf(ctx, port, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Port, int64) int64); ok {
+		r0 = rf(ctx, port, version)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Port, int64) error); ok {
+		r1 = rf(ctx, port, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveImportJobProgress provides a mock function with given fields: ctx, id, summary
+func (_m *RepositoryPort) SaveImportJobProgress(ctx context.Context, id string, summary domain.ImportSummary) (bool, error) {
+	ret := _m.Called(ctx, id, summary)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveImportJobProgress")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ImportSummary) (bool, error)); ok {
+		return rf(ctx, id, summary)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ImportSummary) bool); ok {
+		r0 = rf(ctx, id, summary)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.ImportSummary) error); ok {
+		r1 = rf(ctx, id, summary)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, query
+func (_m *RepositoryPort) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []domain.SearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchQuery) ([]domain.SearchResult, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchQuery) []domain.SearchResult); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SearchQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewRepositoryPort creates a new instance of RepositoryPort. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -7,6 +7,8 @@ import (
 
 	domain "github.com/guil95/ports-service/internal/core/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // ServicePort is an autogenerated mock type for the ServicePort type
@@ -14,6 +16,64 @@ type ServicePort struct {
 	mock.Mock
 }
 
+// BackfillCountryCodes provides a mock function with given fields: ctx
+func (_m *ServicePort) BackfillCountryCodes(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BackfillCountryCodes")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Countries provides a mock function with given fields: ctx
+func (_m *ServicePort) Countries(ctx context.Context) ([]domain.Country, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Countries")
+	}
+
+	var r0 []domain.Country
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Country, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Country); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Country)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateOrUpdate provides a mock function with given fields: ctx, port
 func (_m *ServicePort) CreateOrUpdate(ctx context.Context, port domain.Port) error {
 	ret := _m.Called(ctx, port)
@@ -32,6 +92,54 @@ func (_m *ServicePort) CreateOrUpdate(ctx context.Context, port domain.Port) err
 	return r0
 }
 
+// Delete provides a mock function with given fields: ctx, portID, version
+func (_m *ServicePort) Delete(ctx context.Context, portID string, version int64) error {
+	ret := _m.Called(ctx, portID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, portID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByCode provides a mock function with given fields: ctx, code
+func (_m *ServicePort) FindByCode(ctx context.Context, code string) ([]domain.Port, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByCode")
+	}
+
+	var r0 []domain.Port
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.Port, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.Port); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Port)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByID provides a mock function with given fields: ctx, portID
 func (_m *ServicePort) FindByID(ctx context.Context, portID string) (*domain.Port, error) {
 	ret := _m.Called(ctx, portID)
@@ -62,17 +170,255 @@ func (_m *ServicePort) FindByID(ctx context.Context, portID string) (*domain.Por
 	return r0, r1
 }
 
-// ImportPorts provides a mock function with given fields: ctx
-func (_m *ServicePort) ImportPorts(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// FindNearby provides a mock function with given fields: ctx, query
+func (_m *ServicePort) FindNearby(ctx context.Context, query domain.NearbyQuery) ([]domain.NearbyPort, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindNearby")
+	}
+
+	var r0 []domain.NearbyPort
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NearbyQuery) ([]domain.NearbyPort, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NearbyQuery) []domain.NearbyPort); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NearbyPort)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NearbyQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// History provides a mock function with given fields: ctx, portID
+func (_m *ServicePort) History(ctx context.Context, portID string) ([]domain.PortChange, error) {
+	ret := _m.Called(ctx, portID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for History")
+	}
+
+	var r0 []domain.PortChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.PortChange, error)); ok {
+		return rf(ctx, portID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.PortChange); ok {
+		r0 = rf(ctx, portID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PortChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, portID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportPorts provides a mock function with given fields: ctx, options
+func (_m *ServicePort) ImportPorts(ctx context.Context, options domain.ImportOptions) (*domain.ImportSummary, error) {
+	ret := _m.Called(ctx, options)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ImportPorts")
 	}
 
+	var r0 *domain.ImportSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportOptions) (*domain.ImportSummary, error)); ok {
+		return rf(ctx, options)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportOptions) *domain.ImportSummary); ok {
+		r0 = rf(ctx, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ImportSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ImportOptions) error); ok {
+		r1 = rf(ctx, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportRuns provides a mock function with given fields: ctx, limit
+func (_m *ServicePort) ImportRuns(ctx context.Context, limit int) ([]domain.ImportRun, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportRuns")
+	}
+
+	var r0 []domain.ImportRun
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]domain.ImportRun, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []domain.ImportRun); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ImportRun)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, query
+func (_m *ServicePort) List(ctx context.Context, query domain.ListQuery) (*domain.PortPage, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 *domain.PortPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ListQuery) (*domain.PortPage, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ListQuery) *domain.PortPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PortPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ListQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, portID, patch, version
+func (_m *ServicePort) Patch(ctx context.Context, portID string, patch domain.Patch, version int64) (*domain.Port, error) {
+	ret := _m.Called(ctx, portID, patch, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Patch")
+	}
+
+	var r0 *domain.Port
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Patch, int64) (*domain.Port, error)); ok {
+		return rf(ctx, portID, patch, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Patch, int64) *domain.Port); ok {
+		r0 = rf(ctx, portID, patch, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Port)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Patch, int64) error); ok {
+		r1 = rf(ctx, portID, patch, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, olderThan
+func (_m *ServicePort) Purge(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ret := _m.Called(ctx, olderThan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Purge")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) (int64, error)); ok {
+		return rf(ctx, olderThan)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = rf(ctx, olderThan)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, olderThan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Resolve provides a mock function with given fields: ctx, identifier
+func (_m *ServicePort) Resolve(ctx context.Context, identifier string) (*domain.Port, error) {
+	ret := _m.Called(ctx, identifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resolve")
+	}
+
+	var r0 *domain.Port
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Port, error)); ok {
+		return rf(ctx, identifier)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Port); ok {
+		r0 = rf(ctx, identifier)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Port)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, identifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, portID, version
+func (_m *ServicePort) Restore(ctx context.Context, portID string, version int64) error {
+	ret := _m.Called(ctx, portID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, portID, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -80,6 +426,94 @@ func (_m *ServicePort) ImportPorts(ctx context.Context) error {
 	return r0
 }
 
+// RunImport provides a mock function with given fields: ctx, run, options
+func (_m *ServicePort) RunImport(ctx context.Context, run domain.ImportRun, options domain.ImportOptions) (*domain.ImportRun, error) {
+	ret := _m.Called(ctx, run, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RunImport")
+	}
+
+	var r0 *domain.ImportRun
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportRun, domain.ImportOptions) (*domain.ImportRun, error)); ok {
+		return rf(ctx, run, options)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ImportRun, domain.ImportOptions) *domain.ImportRun); ok {
+		r0 = rf(ctx, run, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ImportRun)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ImportRun, domain.ImportOptions) error); ok {
+		r1 = rf(ctx, run, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, query
+func (_m *ServicePort) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []domain.SearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchQuery) ([]domain.SearchResult, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchQuery) []domain.SearchResult); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SearchQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateIfMatch provides a mock function with given fields: ctx, port, version
+func (_m *ServicePort) UpdateIfMatch(ctx context.Context, port domain.Port, version int64) (int64, error) {
+	ret := _m.Called(ctx, port, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateIfMatch")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Port, int64) (int64, error)); ok {
+		return rf(ctx, port, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Port, int64) int64); ok {
+		r0 = rf(ctx, port, version)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Port, int64) error); ok {
+		r1 = rf(ctx, port, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewServicePort creates a new instance of ServicePort. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewServicePort(t interface {
@@ -3,6 +3,10 @@ package suite
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"testing"
 	"time"
 
@@ -12,7 +16,7 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-func SetupPostgresContainer(t *testing.T) (testcontainers.Container, *sqlx.DB) {
+func SetupPostgresContainer(t testing.TB) (testcontainers.Container, *sqlx.DB) {
 	ctx := context.Background()
 
 	req := testcontainers.ContainerRequest{
@@ -57,22 +61,26 @@ func SetupPostgresContainer(t *testing.T) (testcontainers.Container, *sqlx.DB) {
 	}
 	require.NoError(t, err)
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS ports (
-			id          VARCHAR(50) PRIMARY KEY,
-			name        VARCHAR(100),
-			city        VARCHAR(100),
-			country     VARCHAR(100),
-			alias       TEXT[],
-			regions     TEXT[],
-			coordinates FLOAT[],
-			province    VARCHAR(100),
-			timezone    VARCHAR(50),
-			unlocs      TEXT[],
-			code        VARCHAR(10)
-		);
-	`)
-	require.NoError(t, err)
+	applyMigrations(t, db)
 
 	return postgresContainer, db
 }
+
+// applyMigrations runs every up migration of the project, in order, against the test database.
+func applyMigrations(t testing.TB, db *sqlx.DB) {
+	_, currentFile, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(currentFile), "..", "..", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "no migrations found")
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = db.Exec(string(migration))
+		require.NoError(t, err, "migration %s failed", filepath.Base(file))
+	}
+}